@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/ratmirtech/postgresql-query-monitor/internal/analyzer"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/spf13/cobra"
+)
+
+// newAnalyzer создает анализатор по флагу --analyzer; неверное значение завершает процесс
+func newAnalyzer(cmd *cobra.Command, cfg *config.Config) client.Analyzer {
+	modeStr, _ := cmd.Flags().GetString("analyzer")
+	mode, err := analyzer.ParseMode(modeStr)
+	if err != nil {
+		exitf(ExitUsageError, "❌ Invalid analyzer: %v", err)
+	}
+
+	if mode == analyzer.ModeLocal {
+		return analyzer.New(mode, nil)
+	}
+	return analyzer.New(mode, newReviewClient(cmd, cfg))
+}
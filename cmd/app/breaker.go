@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/spf13/cobra"
+)
+
+// newReviewClient создает клиент review API с повторами и автоматическим выключателем.
+// Для плановых запусков (--st) состояние выключателя сохраняется в файл: пока API недоступен,
+// следующие запуски не отправляют запросы до истечения паузы.
+func newReviewClient(cmd *cobra.Command, cfg *config.Config) *client.Client {
+	policy := client.DefaultRetryPolicy()
+	policy.MaxAttempts = cfg.ReviewAPI.Retries
+	if cmd.Flags().Changed("retries") {
+		policy.MaxAttempts, _ = cmd.Flags().GetInt("retries")
+	}
+
+	breaker := client.NewCircuitBreaker(cfg.ReviewAPI.BreakerThreshold,
+		time.Duration(cfg.ReviewAPI.BreakerCooldownSeconds)*time.Second)
+	if isSchedulerTask, _ := cmd.Flags().GetBool("st"); isSchedulerTask {
+		if path := breakerStatePath(cfg); path != "" {
+			breaker.WithStore(breakerStateFile(path))
+		}
+		if breaker.Open() {
+			log.Println("⚠️ Review API failed in recent runs, requests are paused until the circuit breaker cooldown ends")
+		}
+	}
+
+	return client.NewClient(cfg.ReviewAPI.URL).WithRetry(policy).WithCircuitBreaker(breaker)
+}
+
+// breakerStatePath возвращает файл состояния выключателя: REVIEW_API_BREAKER_STATE
+// или <user cache dir>/pgmon/breaker.json
+func breakerStatePath(cfg *config.Config) string {
+	if cfg.ReviewAPI.BreakerStateFile != "" {
+		return cfg.ReviewAPI.BreakerStateFile
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Printf("⚠️ Circuit breaker state is not shared between runs: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, "pgmon", "breaker.json")
+}
+
+// breakerStateFile хранит состояние выключателя в JSON-файле.
+// Ошибки чтения и записи пишутся в лог: выключатель продолжает работать в пределах запуска.
+type breakerStateFile string
+
+func (f breakerStateFile) Load() client.BreakerState {
+	var state client.BreakerState
+	data, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return state
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to read circuit breaker state: %v", err)
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("⚠️ Corrupted circuit breaker state %s: %v", f, err)
+		return client.BreakerState{}
+	}
+	return state
+}
+
+func (f breakerStateFile) Save(state client.BreakerState) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️ Failed to save circuit breaker state: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(string(f)), 0o755); err != nil {
+		log.Printf("⚠️ Failed to save circuit breaker state: %v", err)
+		return
+	}
+	if err := os.WriteFile(string(f), data, 0o644); err != nil {
+		log.Printf("⚠️ Failed to save circuit breaker state: %v", err)
+	}
+}
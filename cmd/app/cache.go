@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/reviewcache"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
+	"github.com/spf13/cobra"
+)
+
+// openReviewCache открывает кеш ответов review API: флаги переопределяют значения из окружения.
+// Возвращает nil, если кеш отключён через --no-cache или каталог не удалось определить.
+func openReviewCache(cmd *cobra.Command, cfg *config.Config) *reviewcache.Cache {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return nil
+	}
+
+	dir := cfg.ReviewCache.Dir
+	ttl := time.Duration(cfg.ReviewCache.TTLHours) * time.Hour
+	if cmd.Flags().Changed("cache-dir") {
+		dir, _ = cmd.Flags().GetString("cache-dir")
+	}
+	if cmd.Flags().Changed("cache-ttl") {
+		ttl, _ = cmd.Flags().GetDuration("cache-ttl")
+	}
+	if dir == "" {
+		var err error
+		if dir, err = reviewcache.DefaultDir(); err != nil {
+			log.Printf("⚠️ Review cache is disabled: %v", err)
+			return nil
+		}
+	}
+
+	cache := reviewcache.New(dir).WithTTL(ttl)
+	if removed, err := cache.Prune(); err != nil {
+		log.Printf("⚠️ %v", err)
+	} else if removed > 0 {
+		log.Printf("ℹ️ Removed %d expired review cache entries", removed)
+	}
+	return cache
+}
+
+// reviewCacheScope возвращает значения, от которых зависят ответы review API помимо SQL:
+// версию из --pg-version и, если задана целевая база, её версию и отпечаток настроек.
+// При смене версии или настроек сервера ключи меняются, и старые ответы не используются.
+func reviewCacheScope(ctx context.Context, target db.DB, pgVersion int) ([]string, error) {
+	if target == nil {
+		return reviewcache.Scope(pgVersion, "", nil), nil
+	}
+
+	info, err := serverinfo.GetServerInfo(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := serverinfo.GetSnapshot(ctx, target, serverinfo.SnapshotFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return reviewcache.Scope(pgVersion, info.Version, settings), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/drift"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare server configuration between two Vault paths or saved JSON snapshots",
+	Run: func(cmd *cobra.Command, args []string) {
+		var cfg config.Config
+
+		if err := cfg.Load(); err != nil {
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
+		}
+
+		leftVP, _ := cmd.Flags().GetString("left-vp")
+		leftFile, _ := cmd.Flags().GetString("left-file")
+		rightVP, _ := cmd.Flags().GetString("right-vp")
+		rightFile, _ := cmd.Flags().GetString("right-file")
+		ignore, _ := cmd.Flags().GetStringSlice("ignore")
+
+		if (leftVP == "") == (leftFile == "") {
+			exitf(ExitUsageError, "❌ Exactly one of --left-vp or --left-file is required")
+		}
+		if (rightVP == "") == (rightFile == "") {
+			exitf(ExitUsageError, "❌ Exactly one of --right-vp or --right-file is required")
+		}
+
+		output := getOutputOptions(cmd)
+
+		ctx := context.Background()
+
+		var vaultClient *api.Client
+		if leftVP != "" || rightVP != "" {
+			var err error
+			vaultClient, err = api.NewClient(api.DefaultConfig())
+			if err != nil {
+				exitf(ExitCollectionError, "❌ Failed to create Vault client: %v", err)
+			}
+			vaultClient.SetToken(cfg.VaultToken)
+		}
+
+		loadSide := func(vaultPath, file string) drift.Snapshot {
+			if file != "" {
+				data, err := drift.LoadFile(file)
+				if err != nil {
+					exitf(ExitCollectionError, "❌ Failed to load snapshot: %v", err)
+				}
+				return drift.Snapshot{Name: file, Data: data}
+			}
+
+			collector := serverinfo.NewServerInfoCollector(vaultClient, vaultPath)
+			if snapshot, _ := cmd.Flags().GetBool("snapshot"); snapshot {
+				collector.WithSnapshot(snapshotFilter(cmd, &cfg))
+			}
+
+			data, err := collector.CollectServerData(ctx)
+			if err != nil {
+				exitf(ExitCollectionError, "Failed to collect server data from %s: %v", vaultPath, err)
+			}
+			return drift.Snapshot{Name: vaultPath, Data: data}
+		}
+
+		left := loadSide(leftVP, leftFile)
+		right := loadSide(rightVP, rightFile)
+
+		result := drift.Compare(left, right, drift.Options{Ignore: ignore})
+
+		if err := output.write(report.Report{Drift: &result}); err != nil {
+			exitf(ExitUsageError, "❌ Failed to write report: %v", err)
+		}
+
+		if result.HasDrift() {
+			exitf(ExitDriftDetected, "⚠️ Drift detected: %d setting(s), %d extension(s), version changed: %v",
+				len(result.Settings), len(result.Extensions), result.VersionChanged)
+		}
+
+		log.Println("✅ No drift detected")
+	},
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// Коды завершения pgmon. Описаны в README, на них опираются CI-пайплайны.
+const (
+	ExitOK              = 0   // Успешное выполнение
+	ExitUsageError      = 1   // Ошибка конфигурации или неверные флаги
+	ExitThresholdFailed = 2   // Результаты ревью не прошли пороги --min-score / --fail-on
+	ExitAPIUnreachable  = 3   // Review API недоступен или вернул ошибку
+	ExitCollectionError = 4   // Не удалось собрать или проверить данные (SQL-файлы, Vault, PostgreSQL, локальные правила)
+	ExitDriftDetected   = 5   // pgmon diff обнаружил расхождения конфигурации
+	ExitInterrupted     = 130 // Ревью прервано Ctrl-C или SIGTERM
+)
+
+// exitCode код завершения, который команда задаёт вместо os.Exit, чтобы отработали её defer;
+// процесс завершается с ним в main
+var exitCode = ExitOK
+
+// exitf пишет сообщение в лог и завершает процесс с указанным кодом
+func exitf(code int, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(code)
+}
+
+// gateExitCode определяет код завершения csf по результатам ревью и политике гейтинга.
+// Нарушения порогов в проверенных файлах выводятся, даже если часть файлов проверить не удалось;
+// код ошибки проверки важнее кода нарушения порогов.
+func gateExitCode(policy gate.Policy, results []client.FileResult) int {
+	failed, code := 0, ExitOK
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+			code = worseFailure(code, failureExitCode(r.Err))
+		}
+	}
+
+	if policy.Enabled() {
+		violations := policy.Evaluate(results)
+		for _, v := range violations {
+			log.Printf("❌ %s: %s", v.Path, v.Reason)
+		}
+		switch {
+		case len(violations) > 0:
+			log.Printf("❌ Review failed thresholds: %d violation(s)", len(violations))
+			if code == ExitOK {
+				code = ExitThresholdFailed
+			}
+		case failed == 0:
+			log.Println("✅ All files passed review thresholds")
+		}
+	}
+
+	if failed > 0 {
+		log.Printf("❌ %d of %d files could not be reviewed", failed, len(results))
+	}
+	return code
+}
+
+// failureExitCode определяет код завершения по ошибке ревью или анализа: ошибки review API
+// (сеть, ответ с ошибкой, разомкнутый выключатель) отличаются от ошибок локальных правил и прерывания
+func failureExitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case client.IsAPIError(err):
+		return ExitAPIUnreachable
+	default:
+		return ExitCollectionError
+	}
+}
+
+// worseFailure выбирает код, о котором важнее сообщить: прерывание, затем недоступность API
+func worseFailure(a, b int) int {
+	for _, code := range []int{ExitInterrupted, ExitAPIUnreachable, ExitCollectionError} {
+		if a == code || b == code {
+			return code
+		}
+	}
+	return a
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List stored reviews and score trends from the database at VAULT_DB_PATH",
+	Run: func(cmd *cobra.Command, args []string) {
+		var cfg config.Config
+
+		if err := cfg.Load(); err != nil {
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
+		}
+
+		filter, err := historyFilter(cmd, time.Now())
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid history flags: %v", err)
+		}
+		trends, _ := cmd.Flags().GetBool("trends")
+
+		output := getOutputOptions(cmd)
+
+		ctx := context.Background()
+
+		store, err := openStore(ctx, &cfg)
+		if err != nil {
+			exitf(ExitCollectionError, "❌ Failed to open review storage: %v", err)
+		}
+		defer store.Close()
+
+		var r report.Report
+		if trends {
+			if r.Trends, err = store.ListTrends(ctx, filter); err != nil {
+				exitf(ExitCollectionError, "❌ Failed to load score trends: %v", err)
+			}
+			log.Printf("✅ Loaded score trends for %d queries", len(r.Trends))
+		} else {
+			if r.Reviews, err = store.ListReviews(ctx, filter); err != nil {
+				exitf(ExitCollectionError, "❌ Failed to load review history: %v", err)
+			}
+			log.Printf("✅ Loaded %d stored reviews", len(r.Reviews))
+		}
+
+		if err := output.write(r); err != nil {
+			exitf(ExitUsageError, "❌ Failed to write report: %v", err)
+		}
+	},
+}
+
+// historyFilter собирает фильтр выборки из флагов команды history
+func historyFilter(cmd *cobra.Command, now time.Time) (storage.Filter, error) {
+	var f storage.Filter
+	var err error
+
+	f.SourceDatabase, _ = cmd.Flags().GetString("source-db")
+	f.Environment, _ = cmd.Flags().GetString("env")
+	f.Path, _ = cmd.Flags().GetString("path")
+	f.ThreadID, _ = cmd.Flags().GetString("thread")
+	f.Issue, _ = cmd.Flags().GetString("issue")
+	f.Limit, _ = cmd.Flags().GetInt("limit")
+
+	since, _ := cmd.Flags().GetString("since")
+	if f.Since, err = parseTimeFlag(since, now); err != nil {
+		return f, fmt.Errorf("--since: %w", err)
+	}
+	until, _ := cmd.Flags().GetString("until")
+	if f.Until, err = parseTimeFlag(until, now); err != nil {
+		return f, fmt.Errorf("--until: %w", err)
+	}
+
+	if cmd.Flags().Changed("min-score") {
+		minScore, _ := cmd.Flags().GetInt("min-score")
+		f.MinScore = &minScore
+	}
+	if cmd.Flags().Changed("max-score") {
+		maxScore, _ := cmd.Flags().GetInt("max-score")
+		f.MaxScore = &maxScore
+	}
+
+	return f, nil
+}
+
+// parseTimeFlag разбирает момент времени: RFC3339, дату YYYY-MM-DD
+// или длительность назад от текущего момента (30m, 24h, 7d)
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (expected RFC3339, YYYY-MM-DD or duration like 24h, 7d)", value)
+}
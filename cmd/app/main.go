@@ -5,14 +5,23 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/dreadew/go-common/pkg/clients/db"
 	"github.com/dreadew/go-common/pkg/logger"
 	"github.com/hashicorp/vault/api"
 	"github.com/joho/godotenv"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/analyzer"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/explain"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
 	_ "github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
 	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/reviewcache"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
 	"github.com/spf13/cobra"
@@ -24,51 +33,59 @@ var csiCmd = &cobra.Command{
 		var cfg config.Config
 
 		if err := cfg.Load(); err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
 		}
 
 		ctx := context.Background()
 
 		vaultClient, err := api.NewClient(api.DefaultConfig())
 		if err != nil {
-			log.Fatalf("Failed to create Vault client: %v", err)
+			exitf(ExitCollectionError, "❌ Failed to create Vault client: %v", err)
 		}
 
 		vaultClient.SetToken(cfg.VaultToken)
 
 		vaultPath, err := cmd.Flags().GetString("vp")
 		if err != nil {
-			log.Fatalf("Failed to get vault path: %v", err)
+			exitf(ExitUsageError, "❌ Failed to get vault path: %v", err)
 		}
 		if vaultPath == "" {
-			log.Fatalf("Vault path is required")
+			exitf(ExitUsageError, "❌ Vault path is required")
 		}
 
 		isSchedulerTask, err := cmd.Flags().GetBool("st")
 		if err != nil {
-			log.Fatalf("Failed to get scheduler task flag: %v", err)
+			exitf(ExitUsageError, "❌ Failed to get scheduler task flag: %v", err)
 		}
 
+		output := getOutputOptions(cmd)
+		analyzerClient := newAnalyzer(cmd, &cfg)
+
 		collector := serverinfo.NewServerInfoCollector(vaultClient, vaultPath)
 
+		if snapshot, _ := cmd.Flags().GetBool("snapshot"); snapshot {
+			collector.WithSnapshot(snapshotFilter(cmd, &cfg))
+		}
+
 		info, err := collector.CollectServerData(ctx)
 		if err != nil {
-			log.Fatalf("Failed to collect server data: %v", err)
+			exitf(ExitCollectionError, "Failed to collect server data: %v", err)
 		}
 
 		info.Environment = cfg.Environment
 
-		analyzerClient := client.NewClient(cfg.ReviewAPI.URL)
-
 		log.Default().Println("✅ Collected server info, sending for analysis...")
-		log.Default().Printf("Server info: %+v", info)
 
-		report, err := analyzerClient.AnalyzeConfig(ctx, info, isSchedulerTask)
+		recommendation, err := analyzerClient.AnalyzeConfig(ctx, info, isSchedulerTask)
 		if err != nil {
-			log.Fatalf("Failed to analyze config: %v", err)
+			exitf(failureExitCode(err), "Failed to analyze config: %v", err)
 		}
 
-		log.Default().Printf("✅ Received recommendation: %+v", report)
+		log.Default().Println("✅ Received recommendation")
+
+		if err := output.write(report.Report{ServerData: &info, Recommendation: recommendation}); err != nil {
+			exitf(ExitUsageError, "❌ Failed to write report: %v", err)
+		}
 	},
 }
 
@@ -83,6 +100,10 @@ var csfCmd = &cobra.Command{
 		specificFiles, _ := cmd.Flags().GetStringSlice("files")
 		ignoreFiles, _ := cmd.Flags().GetStringSlice("ignore")
 		enableIgnore, _ := cmd.Flags().GetBool("enable-ignore")
+		baseRef, _ := cmd.Flags().GetString("base")
+		migrationsDirs, _ := cmd.Flags().GetStringSlice("migrations-dir")
+		include, _ := cmd.Flags().GetStringSlice("include")
+		exclude, _ := cmd.Flags().GetStringSlice("exclude")
 		//reviewURL, _ := cmd.Flags().GetString("review-url")
 
 		// режим
@@ -92,22 +113,65 @@ var csfCmd = &cobra.Command{
 			mode = sqlfiles.MigrationsOnly
 		case "specific":
 			mode = sqlfiles.SpecificFiles
+		case "changed":
+			mode = sqlfiles.ChangedFiles
+		case "go":
+			mode = sqlfiles.GoFiles
 		default:
 			mode = sqlfiles.AllSQLFiles
 		}
 
-		cfg := sqlfiles.SearchConfig{
+		searchCfg := sqlfiles.SearchConfig{
 			RootPath:          dir,
 			Mode:              mode,
 			MigrationsPath:    migrationsPath,
+			MigrationsDirs:    migrationsDirs,
 			SpecificFileNames: specificFiles,
 			EnableIgnoreList:  enableIgnore,
 			IgnoreFiles:       ignoreFiles,
+			Include:           include,
+			Exclude:           exclude,
+			BaseRef:           baseRef,
+		}
+		vars, err := sqlVars(cmd)
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid variables: %v", err)
+		}
+		searchCfg.Vars = vars
+		if mode == sqlfiles.ChangedFiles && baseRef == "" {
+			exitf(ExitUsageError, "❌ --mode=changed requires --base")
+		}
+
+		minScore, _ := cmd.Flags().GetInt("min-score")
+		failOn, _ := cmd.Flags().GetStringSlice("fail-on")
+		policy, err := gate.ParsePolicy(minScore, failOn)
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid gating flags: %v", err)
+		}
+
+		targetVP, _ := cmd.Flags().GetString("target-vp")
+		explainPlans, _ := cmd.Flags().GetBool("explain")
+		explainAnalyze, _ := cmd.Flags().GetStringSlice("explain-analyze")
+		explainTimeout, _ := cmd.Flags().GetDuration("explain-timeout")
+		splitStatements, _ := cmd.Flags().GetBool("split-statements")
+		pgVersion, _ := cmd.Flags().GetInt("pg-version")
+		analyzerStr, _ := cmd.Flags().GetString("analyzer")
+		analyzerMode, err := analyzer.ParseMode(analyzerStr)
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid analyzer: %v", err)
+		}
+		if (explainPlans || len(explainAnalyze) > 0) && targetVP == "" {
+			exitf(ExitUsageError, "❌ --explain requires --target-vp")
+		}
+		if len(explainAnalyze) > 0 && !explainPlans {
+			exitf(ExitUsageError, "❌ --explain-analyze requires --explain")
 		}
 
-		files, err := sqlfiles.CollectSQLFiles(cfg)
+		output := getOutputOptions(cmd)
+
+		files, err := sqlfiles.CollectSQLFiles(searchCfg)
 		if err != nil {
-			log.Fatalf("❌ Failed to collect SQL files: %v", err)
+			exitf(ExitCollectionError, "❌ Failed to collect SQL files: %v", err)
 		}
 
 		if len(files) == 0 {
@@ -118,59 +182,116 @@ var csfCmd = &cobra.Command{
 		log.Printf("✅ Found %d SQL files", len(files))
 		log.Println("Files:")
 		for _, f := range files {
+			if f.Embedded {
+				log.Printf("- %s:%d (embedded SQL)", f.Path, f.TopLine(1))
+				continue
+			}
+			if m := f.Migration; m != nil && m.Tool != sqlfiles.ToolUnknown {
+				log.Printf("- %s (migration: %s %s %s)", f.Path, m.Tool, m.Version, m.Direction)
+				continue
+			}
 			log.Printf("- %s (migration: %v)", f.Path, f.IsMigration)
 		}
 
-		// Разделяем файлы
-		var migrations []sqlfiles.SQLFile
-		var normal []sqlfiles.SQLFile
-		for _, f := range files {
-			if f.IsMigration {
-				migrations = append(migrations, f)
+		migrationIssues := sqlfiles.CheckMigrations(files)
+		if mode == sqlfiles.ChangedFiles {
+			migrationIssues = append(migrationIssues, changedMigrationIssues(searchCfg, files)...)
+		}
+		for _, issue := range migrationIssues {
+			log.Printf("⚠️ %s: %s", issue.Path, issue.Message)
+		}
+
+		var appCfg config.Config
+		if err := appCfg.Load(); err != nil {
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
+		}
+
+		// Ctrl-C прерывает ревью: незавершённые файлы получают ошибку, отчёт пишется по готовым
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		apiClient := newReviewClient(cmd, &appCfg).WithStatementSplit(splitStatements)
+		configureSubmission(cmd, apiClient)
+
+		// SQL-файлы проверяются встроенными правилами, review API или обоими
+		if analyzerMode != analyzer.ModeRemote {
+			local := analyzer.NewLocalAnalyzer().WithServerVersion(pgVersion).WithQueries(files)
+			apiClient.WithMigrationReviewer(analyzer.NewWithLocal(analyzerMode, local, apiClient))
+			apiClient.WithLocalQueryReview(local, analyzerMode == analyzer.ModeBoth)
+		}
+
+		// Метаданные таблиц и планы из целевой базы прикладываются к запросам на ревью
+		var targetDB db.DB
+		if targetVP != "" {
+			target, err := openTargetDB(ctx, &appCfg, targetVP)
+			if err != nil {
+				log.Printf("❌ Failed to connect to target database: %v", err)
+				exitCode = ExitCollectionError
+				return
+			}
+			defer target.Close()
+			targetDB = target.DB()
+
+			enrichers := []client.QueryEnricher{serverinfo.NewTableInfoEnricher(target.DB())}
+			if explainPlans {
+				explainer := explain.NewExplainer(target.DB()).
+					WithAnalyze(explainAnalyze).
+					WithStatementTimeout(explainTimeout)
+				enrichers = append(enrichers, explainer)
+			}
+			apiClient.WithEnrichers(enrichers...)
+		}
+
+		// Ответы review API переиспользуются для неизменённого SQL, пока не сменится сервер
+		var cache *reviewcache.Cache
+		if analyzerMode != analyzer.ModeLocal {
+			cache = openReviewCache(cmd, &appCfg)
+		}
+		if cache != nil {
+			scope, err := reviewCacheScope(ctx, targetDB, pgVersion)
+			if err != nil {
+				log.Printf("⚠️ Review cache is disabled: failed to read target server settings: %v", err)
+				cache = nil
 			} else {
-				normal = append(normal, f)
+				apiClient.WithCache(cache, scope...)
+			}
+		}
+
+		// Обычные файлы отправляются пачкой, миграции — по одной
+		results := apiClient.ReviewFiles(ctx, files, appCfg.Environment)
+
+		if ctx.Err() != nil {
+			log.Println("⚠️ Review interrupted, writing results of finished files")
+		}
+		log.Printf("✅ Reviewed %d SQL files (%d results)", len(files), len(results))
+		if cache != nil {
+			hits, stale := cache.Stats()
+			log.Printf("💾 Review cache: %d reused, %d expired", hits, stale)
+		}
+		addMigrationIssues(results, migrationIssues)
+
+		if store, _ := cmd.Flags().GetBool("store"); store {
+			sourceDB, _ := cmd.Flags().GetString("source-db")
+			if sourceDB == "" {
+				sourceDB = sourceDatabaseName(dir)
+			}
+			isSchedulerTask, _ := cmd.Flags().GetBool("st")
+			// Ошибка сохранения не влияет на код завершения: результаты ревью уже получены.
+			// После Ctrl-C ctx уже отменён, поэтому готовые результаты сохраняются с отдельным таймаутом.
+			storeCtx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			if err := saveFileReviews(storeCtx, &appCfg, sourceDB, isSchedulerTask, results); err != nil {
+				log.Printf("⚠️ Failed to store reviews: %v", err)
 			}
+			cancel()
 		}
 
-		// // Создаём клиента
-		// ctx := context.Background()
-		// apiClient := client.NewClient(cfg.ReviewAPI.URL)
-
-		// // Отправляем обычные файлы пачкой
-		// if len(normal) > 0 {
-		// 	var queries []models.QueryReviewRequest
-		// 	for _, f := range normal {
-		// 		queries = append(queries, models.QueryReviewRequest{
-		// 			SQL:         f.Content,         // сам SQL
-		// 			ThreadID:    f.Title,           // для идентификации файла
-		// 			Environment: "production",      // можно брать из cfg
-		// 		})
-		// 	}
-		// 	batch := models.BatchReviewRequest{
-		// 		Queries:     queries,
-		// 		Environment: "production",
-		// 	}
-
-		// 	resp, err := apiClient.ReviewBatchQueries(ctx, batch)
-		// 	if err != nil {
-		// 		log.Fatalf("❌ Failed to review batch queries: %v", err)
-		// 	}
-		// 	log.Printf("✅ Batch review response: %+v", resp)
-		// }
-
-		// // Миграции — по одной
-		// for _, f := range migrations {
-		// 	req := models.MigrationReviewRequest{
-		// 		SQL:         f.Content,     // сам SQL
-		// 		Environment: "production",  // можно брать из cfg
-		// 	}
-		// 	resp, err := apiClient.ReviewMigration(ctx, req)
-		// 	if err != nil {
-		// 		log.Fatalf("❌ Failed to review migration %s: %v", f.Title, err)
-		// 	}
-		// 	log.Printf("✅ Migration %s review response: %+v", f.Title, resp)
-		// }
+		if err := output.write(report.Report{Files: results}); err != nil {
+			log.Printf("❌ Failed to write report: %v", err)
+			exitCode = ExitUsageError
+			return
+		}
 
+		// Код завершения возвращается через main, чтобы закрылись подключения и обработчик сигналов
+		exitCode = gateExitCode(policy, results)
 	},
 }
 var csmCmd = &cobra.Command{
@@ -180,14 +301,17 @@ var csmCmd = &cobra.Command{
 		var cfg config.Config
 
 		if err := cfg.Load(); err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
 		}
 
 		isSchedulerTask, err := cmd.Flags().GetBool("st")
 		if err != nil {
-			log.Fatalf("Failed to get scheduler task flag: %v", err)
+			exitf(ExitUsageError, "❌ Failed to get scheduler task flag: %v", err)
 		}
 
+		output := getOutputOptions(cmd)
+		analyzerClient := newAnalyzer(cmd, &cfg)
+
 		collector := collectors.NewSysMetricsCollector()
 		metrics := collector.Collect()
 
@@ -195,52 +319,133 @@ var csmCmd = &cobra.Command{
 		
 		vaultClient, err := api.NewClient(api.DefaultConfig())
 		if err != nil {
-			log.Fatalf("Failed to create Vault client: %v", err)
+			exitf(ExitCollectionError, "❌ Failed to create Vault client: %v", err)
 		}
 		
 		vaultClient.SetToken(cfg.VaultToken)
 
 		vaultPath, err := cmd.Flags().GetString("vp")
 		if err != nil {
-			log.Fatalf("Failed to get vault path: %v", err)
+			exitf(ExitUsageError, "❌ Failed to get vault path: %v", err)
 		}
 		if vaultPath == "" {
-			log.Fatalf("Vault path is required")
+			exitf(ExitUsageError, "❌ Vault path is required")
 		}
 
 		collectorSI := serverinfo.NewServerInfoCollector(vaultClient, vaultPath)
 		info, err := collectorSI.CollectServerInfo(ctx)
 		if err != nil {
-			log.Fatalf("Failed to collect server info: %v", err)
+			exitf(ExitCollectionError, "Failed to collect server info: %v", err)
 		}
-		
-		analyzerClient := client.NewClient(cfg.ReviewAPI.URL)
-		report, err := analyzerClient.AnalyzeSystemMetrics(ctx, metrics, info, cfg.Environment, isSchedulerTask)
+
+		recommendation, err := analyzerClient.AnalyzeSystemMetrics(ctx, metrics, info, cfg.Environment, isSchedulerTask)
 		if err != nil {
-			log.Fatalf("Failed to analyze system metrics: %v", err)
+			exitf(failureExitCode(err), "Failed to analyze system metrics: %v", err)
 		}
 
-		log.Printf("✅ Received recommendation: %+v", report)
+		log.Println("✅ Received recommendation")
+
+		err = output.write(report.Report{
+			ServerInfo:     &info,
+			SystemMetrics:  &metrics,
+			Recommendation: recommendation,
+		})
+		if err != nil {
+			exitf(ExitUsageError, "❌ Failed to write report: %v", err)
+		}
 	},
 }
 
 func init() {
 	csiCmd.Flags().String("vp", "", "Vault path")
 	csiCmd.Flags().Bool("st", false, "Is scheduler task")
+	csiCmd.Flags().Int("retries", 3, "Attempts per review API request including the first one (overrides REVIEW_API_RETRIES)")
+	csiCmd.Flags().String("analyzer", "remote", "Analyzer: local | remote | both")
+	csiCmd.Flags().Bool("snapshot", false, "Capture all pg_settings instead of the fixed parameter list")
+	csiCmd.Flags().StringSlice("categories", []string{}, "Snapshot only these pg_settings categories (prefix match)")
+	csiCmd.Flags().StringSlice("allow", []string{}, "Snapshot only settings matching these name patterns")
+	csiCmd.Flags().StringSlice("deny", []string{}, "Exclude settings matching these name patterns from snapshot")
 	
 	csfCmd.Flags().String("dir", ".", "Directory to scan")
-	csfCmd.Flags().String("mode", "all", "Search mode: all | migrations | specific")
+	csfCmd.Flags().String("mode", "all", "Search mode: all | migrations | specific | changed | go")
+	csfCmd.Flags().String("base", "", "Git ref to compare against (used if --mode=changed)")
 	csfCmd.Flags().String("vp", "", "Migrations path (used if --mode=migrations)")
 	csfCmd.Flags().StringSlice("files", []string{}, "Specific file names (used if --mode=specific)")
 	csfCmd.Flags().Bool("enable-ignore", false, "Enable ignore list")
-	csfCmd.Flags().StringSlice("ignore", []string{}, "Files to ignore")
+	csfCmd.Flags().StringSlice("ignore", []string{}, "Files to ignore (gitignore-style patterns)")
+	csfCmd.Flags().StringArray("var", []string{}, "psql/template variable key=value substituted before review (repeatable)")
+	csfCmd.Flags().String("vars-file", "", "File with key=value variables substituted before review")
+	csfCmd.Flags().StringSlice("include", []string{}, "Review only paths matching these gitignore-style patterns")
+	csfCmd.Flags().StringSlice("exclude", []string{}, "Skip paths matching these gitignore-style patterns")
+	csfCmd.Flags().StringSlice("migrations-dir", []string{}, "Directories with migrations relative to --dir (default: directories named migrations)")
+	csfCmd.Flags().Int("min-score", 0, "Fail with exit code 2 if any file scores below this value (0 disables)")
+	csfCmd.Flags().String("target-vp", "", "Vault path of the target database to attach table metadata to query reviews")
+	csfCmd.Flags().Bool("explain", false, "Attach EXPLAIN (FORMAT JSON) plans of read-only queries (requires --target-vp)")
+	csfCmd.Flags().StringSlice("explain-analyze", []string{}, "File patterns allowed to run EXPLAIN (ANALYZE, BUFFERS) in a rolled-back read-only transaction")
+	csfCmd.Flags().Duration("explain-timeout", 30*time.Second, "statement_timeout for building plans")
+	csfCmd.Flags().String("analyzer", "remote", "Analyzer for SQL files: local | remote | both")
+	csfCmd.Flags().Int("pg-version", 0, "Major PostgreSQL version migrations are applied to (for local migration rules)")
+	csfCmd.Flags().Bool("split-statements", false, "Review every statement of multi-statement query files separately")
+	csfCmd.Flags().Int("concurrency", 4, "Number of review requests sent in parallel")
+	csfCmd.Flags().Int("batch-size", 50, "Number of queries sent in one batch request (0 sends all in one)")
+	csfCmd.Flags().Float64("rate-limit", 0, "Maximum review API requests per second (0 disables the limit)")
+	csfCmd.Flags().Int("rate-burst", 1, "Number of requests allowed above the rate limit at once")
+	csfCmd.Flags().Duration("api-timeout", 30*time.Second, "Timeout of a single review API request")
+	csfCmd.Flags().Int("retries", 3, "Attempts per review API request including the first one (overrides REVIEW_API_RETRIES)")
+	csfCmd.Flags().Bool("no-cache", false, "Do not reuse or store review API responses in the local cache")
+	csfCmd.Flags().String("cache-dir", "", "Review cache directory (default: REVIEW_CACHE_DIR or the user cache directory)")
+	csfCmd.Flags().Duration("cache-ttl", 7*24*time.Hour, "Maximum age of reused review responses (0 keeps them forever)")
+	csfCmd.Flags().Bool("store", false, "Store review requests and responses in the database at VAULT_DB_PATH")
+	csfCmd.Flags().Bool("st", false, "Is scheduler task (stored reviews may be downsampled by retention)")
+	csfCmd.Flags().String("source-db", "", "Source database name for stored reviews (defaults to the scanned directory name)")
+	csfCmd.Flags().StringSlice("fail-on", []string{}, "Fail with exit code 2 on: issues | warnings | low | medium | high | critical")
 
 	csmCmd.Flags().String("vp", "", "Vault path")
 	csmCmd.Flags().Bool("st", false, "Is scheduler task")
-
-	rootCmd.AddCommand(csiCmd, csfCmd, csmCmd)
-	
-	rootCmd.AddCommand(csiCmd, csfCmd, csmCmd)
+	csmCmd.Flags().Int("retries", 3, "Attempts per review API request including the first one (overrides REVIEW_API_RETRIES)")
+	csmCmd.Flags().String("analyzer", "remote", "Analyzer: local | remote | both")
+
+	tuneCmd.Flags().String("vp", "", "Vault path")
+	tuneCmd.Flags().String("profile", "web", "Workload profile: web | oltp | dw | mixed")
+	tuneCmd.Flags().String("storage", "ssd", "Storage type: ssd | hdd | san")
+	tuneCmd.Flags().Int("max-connections", 0, "Expected max_connections (0 uses profile default)")
+	tuneCmd.Flags().Int("ram-gb", 0, "Override total RAM in GB (defaults to this machine)")
+	tuneCmd.Flags().Int("cpus", 0, "Override CPU cores (defaults to this machine)")
+
+	diffCmd.Flags().String("left-vp", "", "Vault path of the left (baseline) server")
+	diffCmd.Flags().String("left-file", "", "Saved JSON snapshot for the left side (csi --format json)")
+	diffCmd.Flags().String("right-vp", "", "Vault path of the right (compared) server")
+	diffCmd.Flags().String("right-file", "", "Saved JSON snapshot for the right side (csi --format json)")
+	diffCmd.Flags().Bool("snapshot", false, "Collect all pg_settings from live servers instead of the fixed parameter list")
+	diffCmd.Flags().StringSlice("categories", []string{}, "Snapshot only these pg_settings categories (prefix match)")
+	diffCmd.Flags().StringSlice("allow", []string{}, "Snapshot only settings matching these name patterns")
+	diffCmd.Flags().StringSlice("deny", []string{}, "Exclude settings matching these name patterns from snapshot")
+	diffCmd.Flags().StringSlice("ignore", []string{}, "Setting name patterns to exclude from comparison")
+
+	historyCmd.Flags().String("source-db", "", "Filter by source database")
+	historyCmd.Flags().String("env", "", "Filter by environment")
+	historyCmd.Flags().String("since", "", "Reviews created at or after: RFC3339, YYYY-MM-DD or duration ago (24h, 7d)")
+	historyCmd.Flags().String("until", "", "Reviews created before: RFC3339, YYYY-MM-DD or duration ago (24h, 7d)")
+	historyCmd.Flags().Int("min-score", 0, "Minimum overall score")
+	historyCmd.Flags().Int("max-score", 100, "Maximum overall score")
+	historyCmd.Flags().String("path", "", "Filter by SQL file path substring")
+	historyCmd.Flags().String("thread", "", "Filter by thread_id sent to the Review API")
+	historyCmd.Flags().String("issue", "", "Filter by exact issue text reported by the Review API")
+	historyCmd.Flags().Int("limit", 100, "Maximum number of reviews (0 for no limit)")
+	historyCmd.Flags().Bool("trends", false, "Show score trends grouped by SQL fingerprint")
+
+	pruneCmd.Flags().Int("days", 0, "Delete reviews older than N days (overrides RETENTION_DAYS)")
+	pruneCmd.Flags().Int("keep-last", 0, "Keep only the last N reviews per source database (overrides RETENTION_KEEP_LAST)")
+	pruneCmd.Flags().Bool("downsample", false, "Keep one review per query per day for scheduler runs (overrides RETENTION_DOWNSAMPLE)")
+	pruneCmd.Flags().String("archive-dir", "", "Archive pruned reviews to compressed JSONL files in this directory (overrides RETENTION_ARCHIVE_DIR)")
+	pruneCmd.Flags().Bool("dry-run", false, "Only count reviews matching the policy")
+	pruneCmd.Flags().Duration("interval", 0, "Run as a daemon, pruning every interval (e.g. 1h)")
+	historyCmd.AddCommand(pruneCmd)
+
+	rootCmd.PersistentFlags().String("format", "text", "Output format: text | json | yaml | markdown | sarif | junit | csv (sarif and junit: csf only, csv: history only)")
+	rootCmd.PersistentFlags().String("output", "", "Write output to file instead of stdout")
+
+	rootCmd.AddCommand(csiCmd, csfCmd, csmCmd, tuneCmd, diffCmd, historyCmd)
 }
 var rootCmd = &cobra.Command{
 	Use:   "pgmon",
@@ -251,7 +456,10 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(ExitUsageError)
+	}
+	if exitCode != ExitOK {
+		os.Exit(exitCode)
 	}
 }
 
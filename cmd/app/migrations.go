@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// addMigrationIssues добавляет проблемы порядка и пар up/down к предупреждениям ревью миграций,
+// чтобы они попали в отчёты и учитывались --fail-on=warnings
+func addMigrationIssues(results []client.FileResult, issues []sqlfiles.MigrationIssue) {
+	for _, issue := range issues {
+		for i := range results {
+			r := &results[i]
+			if r.Path == issue.Path || (r.Migration != nil && r.Migration.DownPath == issue.Path) {
+				r.Warnings = append(r.Warnings, issue.Message)
+				break
+			}
+		}
+	}
+}
+
+// changedMigrationIssues сравнивает изменённые миграции с уже существующими в каталоге миграций.
+// Если каталог не удалось прочитать, проверка пропускается.
+func changedMigrationIssues(searchCfg sqlfiles.SearchConfig, changed []sqlfiles.SQLFile) []sqlfiles.MigrationIssue {
+	hasMigrations := false
+	for _, f := range changed {
+		hasMigrations = hasMigrations || f.IsMigration
+	}
+	if !hasMigrations {
+		return nil
+	}
+
+	searchCfg.Mode = sqlfiles.MigrationsOnly
+	existing, err := sqlfiles.CollectSQLFiles(searchCfg)
+	if err != nil {
+		log.Printf("⚠️ Failed to collect existing migrations: %v", err)
+		return nil
+	}
+	return sqlfiles.CheckChangedMigrations(changed, existing)
+}
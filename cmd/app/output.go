@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// outputOptions значения глобальных флагов --format и --output
+type outputOptions struct {
+	format report.Format
+	path   string
+}
+
+// getOutputOptions читает глобальные флаги вывода; неверный формат завершает процесс
+func getOutputOptions(cmd *cobra.Command) outputOptions {
+	formatStr, _ := cmd.Flags().GetString("format")
+	path, _ := cmd.Flags().GetString("output")
+
+	format, err := report.ParseFormat(formatStr)
+	if err != nil {
+		exitf(ExitUsageError, "❌ Invalid output format: %v", err)
+	}
+
+	return outputOptions{format: format, path: path}
+}
+
+// write выводит отчёт в stdout или в файл из --output
+func (o outputOptions) write(r report.Report) error {
+	return writeOutput(o.path, func(w io.Writer) error {
+		return report.Write(w, o.format, r)
+	})
+}
+
+// openOutput открывает файл для отчёта или возвращает stdout, если путь не задан
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return file, nil
+}
+
+// writeOutput открывает вывод, вызывает функцию записи и закрывает файл
+func writeOutput(path string, write func(w io.Writer) error) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	if path != "" {
+		log.Printf("💾 Report saved to %s", path)
+	}
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
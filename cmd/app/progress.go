@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/spf13/cobra"
+)
+
+// progressWidth ширина полосы прогресса в символах
+const progressWidth = 30
+
+// newProgressBar возвращает индикатор прогресса ревью с оценкой оставшегося времени,
+// который перерисовывает строку в out. Если out не терминал (CI, перенаправление в файл), возвращает nil.
+func newProgressBar(out *os.File) client.ProgressFunc {
+	if !isTerminal(out) {
+		return nil
+	}
+
+	start := time.Now()
+	return func(done, total int) {
+		if total == 0 {
+			return
+		}
+		filled := done * progressWidth / total
+		line := fmt.Sprintf("\r[%s%s] %d/%d %3d%%",
+			strings.Repeat("#", filled), strings.Repeat(".", progressWidth-filled), done, total, done*100/total)
+
+		if done < total && done > 0 {
+			elapsed := time.Since(start)
+			eta := time.Duration(float64(elapsed) / float64(done) * float64(total-done))
+			line += fmt.Sprintf(" ETA %s", eta.Round(time.Second))
+		}
+		// Пробелы затирают остаток предыдущей, более длинной строки
+		fmt.Fprintf(out, "%-70s", line)
+		if done == total {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+// isTerminal сообщает, подключён ли файл к терминалу
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// configureSubmission настраивает параллельную отправку на ревью: число потоков, размер пачки,
+// лимит запросов, таймаут и индикатор прогресса в stderr
+func configureSubmission(cmd *cobra.Command, apiClient *client.Client) {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	rateLimit, _ := cmd.Flags().GetFloat64("rate-limit")
+	rateBurst, _ := cmd.Flags().GetInt("rate-burst")
+	timeout, _ := cmd.Flags().GetDuration("api-timeout")
+
+	apiClient.WithConcurrency(concurrency).
+		WithBatchSize(batchSize).
+		WithRateLimit(rateLimit, rateBurst).
+		WithTimeout(timeout)
+	if progress := newProgressBar(os.Stderr); progress != nil {
+		apiClient.WithProgress(progress)
+	}
+}
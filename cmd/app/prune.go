@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Prune or archive stored reviews according to the retention policy",
+	Run: func(cmd *cobra.Command, args []string) {
+		var cfg config.Config
+
+		if err := cfg.Load(); err != nil {
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
+		}
+
+		policy, opts := retentionPolicy(cmd, &cfg)
+		if !policy.Enabled() {
+			exitf(ExitUsageError, "❌ Retention policy is empty: set --days, --keep-last or --downsample")
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		// В режиме демона очистка повторяется до SIGINT/SIGTERM
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		store, err := openStore(ctx, &cfg)
+		if err != nil {
+			exitf(ExitCollectionError, "❌ Failed to open review storage: %v", err)
+		}
+		defer store.Close()
+
+		for {
+			if err := runPrune(ctx, store, policy, opts); err != nil {
+				if interval <= 0 {
+					exitf(ExitCollectionError, "❌ Failed to prune reviews: %v", err)
+				}
+				log.Printf("⚠️ Failed to prune reviews: %v", err)
+			}
+			if interval <= 0 {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				log.Println("ℹ️ Retention daemon stopped")
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+// runPrune выполняет один проход очистки и пишет итог в лог
+func runPrune(ctx context.Context, store *storage.Store, policy storage.RetentionPolicy, opts storage.PruneOptions) error {
+	result, err := store.Prune(ctx, policy, opts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.DryRun:
+		log.Printf("ℹ️ %d reviews match the retention policy (dry run)", result.Matched)
+	case result.ArchivePath != "":
+		log.Printf("💾 Archived %d reviews to %s", result.Matched, result.ArchivePath)
+		log.Printf("✅ Deleted %d reviews", result.Deleted)
+	default:
+		log.Printf("✅ Deleted %d reviews", result.Deleted)
+	}
+	return nil
+}
+
+// retentionPolicy собирает политику хранения: флаги переопределяют значения из окружения
+func retentionPolicy(cmd *cobra.Command, cfg *config.Config) (storage.RetentionPolicy, storage.PruneOptions) {
+	days := cfg.Retention.Days
+	keepLast := cfg.Retention.KeepLast
+	downsample := cfg.Retention.Downsample
+	archiveDir := cfg.Retention.ArchiveDir
+
+	if cmd.Flags().Changed("days") {
+		days, _ = cmd.Flags().GetInt("days")
+	}
+	if cmd.Flags().Changed("keep-last") {
+		keepLast, _ = cmd.Flags().GetInt("keep-last")
+	}
+	if cmd.Flags().Changed("downsample") {
+		downsample, _ = cmd.Flags().GetBool("downsample")
+	}
+	if cmd.Flags().Changed("archive-dir") {
+		archiveDir, _ = cmd.Flags().GetString("archive-dir")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	policy := storage.RetentionPolicy{
+		MaxAge:     time.Duration(days) * 24 * time.Hour,
+		KeepLast:   keepLast,
+		Downsample: downsample,
+	}
+	return policy, storage.PruneOptions{ArchiveDir: archiveDir, DryRun: dryRun}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
+	"github.com/spf13/cobra"
+)
+
+// snapshotFilter собирает фильтр снимка pg_settings: флаги переопределяют значения из окружения
+func snapshotFilter(cmd *cobra.Command, cfg *config.Config) serverinfo.SnapshotFilter {
+	filter := serverinfo.SnapshotFilter{
+		Categories: cfg.Snapshot.Categories,
+		Allow:      cfg.Snapshot.Allow,
+		Deny:       cfg.Snapshot.Deny,
+	}
+
+	if cmd.Flags().Changed("categories") {
+		filter.Categories, _ = cmd.Flags().GetStringSlice("categories")
+	}
+	if cmd.Flags().Changed("allow") {
+		filter.Allow, _ = cmd.Flags().GetStringSlice("allow")
+	}
+	if cmd.Flags().Changed("deny") {
+		filter.Deny, _ = cmd.Flags().GetStringSlice("deny")
+	}
+
+	return filter
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+)
+
+// openStore подключается к хранилищу результатов ревью по пути Vault из VAULT_DB_PATH
+func openStore(ctx context.Context, cfg *config.Config) (*storage.Store, error) {
+	if cfg.VaultPath == "" {
+		return nil, fmt.Errorf("VAULT_DB_PATH is not set")
+	}
+
+	vaultClient, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+	vaultClient.SetToken(cfg.VaultToken)
+
+	return storage.Open(ctx, vaultClient, cfg.VaultPath)
+}
+
+// storeTimeout время на сохранение результатов ревью, в том числе после прерывания
+const storeTimeout = 30 * time.Second
+
+// saveFileReviews сохраняет результаты ревью SQL-файлов в хранилище
+func saveFileReviews(ctx context.Context, cfg *config.Config, sourceDatabase string, schedulerTask bool, results []client.FileResult) error {
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	reviews := storage.FileReviews(sourceDatabase, cfg.Environment, schedulerTask, results)
+	if err := store.SaveReviews(ctx, reviews); err != nil {
+		return err
+	}
+
+	log.Printf("💾 Stored %d reviews", len(reviews))
+	return nil
+}
+
+// sourceDatabaseName имя источника по умолчанию для ревью файлов — имя сканируемой директории
+func sourceDatabaseName(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return filepath.Base(abs)
+	}
+	return filepath.Base(dir)
+}
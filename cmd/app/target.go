@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/hashicorp/vault/api"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
+)
+
+// openTargetDB подключается к целевой базе, для которой ревьюятся SQL-файлы
+func openTargetDB(ctx context.Context, cfg *config.Config, vaultPath string) (db.DatabaseClient, error) {
+	vaultClient, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+	vaultClient.SetToken(cfg.VaultToken)
+
+	return serverinfo.CreateDbWrap(ctx, serverinfo.NewServerInfoCollector(vaultClient, vaultPath))
+}
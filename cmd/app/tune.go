@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/serverinfo"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/tuning"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
+	"github.com/spf13/cobra"
+)
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Recommend pgtune-style configuration based on system metrics and workload profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		var cfg config.Config
+
+		if err := cfg.Load(); err != nil {
+			exitf(ExitUsageError, "❌ Failed to load config: %v", err)
+		}
+
+		vaultPath, _ := cmd.Flags().GetString("vp")
+		if vaultPath == "" {
+			exitf(ExitUsageError, "❌ Vault path is required")
+		}
+
+		profileStr, _ := cmd.Flags().GetString("profile")
+		profile, err := tuning.ParseProfile(profileStr)
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid profile: %v", err)
+		}
+
+		storageStr, _ := cmd.Flags().GetString("storage")
+		storage, err := tuning.ParseStorage(storageStr)
+		if err != nil {
+			exitf(ExitUsageError, "❌ Invalid storage: %v", err)
+		}
+
+		maxConnections, _ := cmd.Flags().GetInt("max-connections")
+		ramGB, _ := cmd.Flags().GetInt("ram-gb")
+		cpus, _ := cmd.Flags().GetInt("cpus")
+
+		output := getOutputOptions(cmd)
+
+		// Метрики снимаются с текущей машины; для удалённого сервера их можно переопределить флагами
+		metrics := collectors.NewSysMetricsCollector().Collect()
+		if ramGB > 0 {
+			metrics.RAMTotal = uint64(int64(ramGB) * units.GB)
+		}
+		if cpus > 0 {
+			metrics.CPUCores = cpus
+		}
+
+		ctx := context.Background()
+
+		vaultClient, err := api.NewClient(api.DefaultConfig())
+		if err != nil {
+			exitf(ExitCollectionError, "❌ Failed to create Vault client: %v", err)
+		}
+
+		vaultClient.SetToken(cfg.VaultToken)
+
+		collector := serverinfo.NewServerInfoCollector(vaultClient, vaultPath)
+		settings, err := collector.CollectSettings(ctx)
+		if err != nil {
+			exitf(ExitCollectionError, "Failed to collect config: %v", err)
+		}
+		current := models.ServerData{
+			Config:   serverinfo.ConfigFromSettings(settings),
+			Settings: settings,
+		}
+
+		changes, err := tuning.Recommend(current, metrics, tuning.Options{
+			Profile:        profile,
+			Storage:        storage,
+			MaxConnections: maxConnections,
+		})
+		if err != nil {
+			exitf(ExitCollectionError, "Failed to calculate recommendations: %v", err)
+		}
+
+		log.Printf("✅ Calculated recommendations for %s workload on %s storage", profile, storage)
+
+		if err := output.write(report.Report{Tuning: changes}); err != nil {
+			exitf(ExitUsageError, "❌ Failed to write report: %v", err)
+		}
+	},
+}
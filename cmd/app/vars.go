@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+	"github.com/spf13/cobra"
+)
+
+// sqlVars собирает переменные для развёртывания SQL-файлов: --var переопределяют значения из --vars-file
+func sqlVars(cmd *cobra.Command) (map[string]string, error) {
+	vars := make(map[string]string)
+	if path, _ := cmd.Flags().GetString("vars-file"); path != "" {
+		loaded, err := sqlfiles.LoadVars(path)
+		if err != nil {
+			return nil, err
+		}
+		vars = loaded
+	}
+
+	pairs, _ := cmd.Flags().GetStringArray("var")
+	for _, pair := range pairs {
+		key, value, err := sqlfiles.ParseVar(pair)
+		if err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -17,7 +16,9 @@ import (
 
 	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/config"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/pglogs"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/report"
 )
 
 func main() {
@@ -44,7 +45,7 @@ func main() {
 
 	info, e := collector.CollectServerData(ctx)
 	if e != nil {
-		fmt.Errorf("")
+		log.Printf("Failed to collect server data: %v", e)
 	}
 	info.Environment = "production"
 	info.Config.EffectiveIOConcurrency = "4"
@@ -196,30 +197,25 @@ func runServerInfoCollector(ctx context.Context, vaultClient *api.Client, vaultP
 
 	info, e := collector.CollectServerData(ctx)
 	if e != nil {
-		return fmt.Errorf("")
+		return fmt.Errorf("failed to collect server data: %w", e)
 	}
 	info.Environment = "production"
 
-	log.Printf("✅ Collected server info: %+v", info)
+	log.Printf("✅ Collected server info for %s", info.ServerInfo.Database)
 
-	// Сохраняем info в одну строку JSON
-	jsonStr, err := json.Marshal(info)
-	if err != nil {
-		return fmt.Errorf("failed to marshal server info to JSON: %w", err)
-	}
-	return saveServerInfoToFile(string(jsonStr), outputFile)
+	return saveServerInfoToFile(info, outputFile)
 }
 
-func saveServerInfoToFile(info string, outputFile string) error {
+func saveServerInfoToFile(info models.ServerData, outputFile string) error {
 	file, err := os.Create(outputFile)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	fmt.Fprintf(file, "PostgreSQL Server Information\n")
-	fmt.Fprintf(file, "============================\n\n")
-	fmt.Fprintf(file, "%s\n", info)
+	if err := report.Write(file, report.FormatText, report.Report{ServerData: &info}); err != nil {
+		return err
+	}
 
 	log.Printf("💾 Server info saved to %s", outputFile)
 	return nil
@@ -232,26 +228,9 @@ func saveSysMetricsToFile(metrics collectors.SystemMetrics, filename string) err
 	}
 	defer file.Close()
 
-	fmt.Fprintf(file, "System Metrics Report\n")
-	fmt.Fprintf(file, "====================\n\n")
-	fmt.Fprintf(file, "Timestamp: %s\n\n", metrics.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Fprintf(file, "CPU Information:\n")
-	fmt.Fprintf(file, "  Cores: %d\n", metrics.CPUCores)
-	fmt.Fprintf(file, "  Load Ratio: %.1f%%\n\n", metrics.CPULoad)
-	fmt.Fprintf(file, "Memory Information:\n")
-	fmt.Fprintf(file, "  Total RAM: %d MB\n", metrics.RAMTotal/1024/1024)
-	fmt.Fprintf(file, "  Used RAM: %d MB\n", metrics.RAMUsed/1024/1024)
-	fmt.Fprintf(file, "  Free RAM: %d MB\n\n", metrics.RAMFree/1024/1024)
-	fmt.Fprintf(file, "Disk Information:\n")
-	fmt.Fprintf(file, "  Total Disk: %d GB\n", metrics.DiskTotal/1024/1024/1024)
-	fmt.Fprintf(file, "  Used Disk: %d GB\n", metrics.DiskUsed/1024/1024/1024)
-	fmt.Fprintf(file, "  Free Disk: %d GB\n\n", metrics.DiskFree/1024/1024/1024)
-	fmt.Fprintf(file, "Go Runtime Information:\n")
-	fmt.Fprintf(file, "  Goroutines: %d\n", metrics.Goroutines)
-	fmt.Fprintf(file, "  GC Pauses: %d ns\n", metrics.GCPauses)
-	fmt.Fprintf(file, "  Heap Alloc: %d MB\n", metrics.HeapAlloc/1024/1024)
-	fmt.Fprintf(file, "  Heap Sys: %d MB\n", metrics.HeapSys/1024/1024)
-	fmt.Fprintf(file, "  Stack In Use: %d KB\n", metrics.StackInUse/1024)
+	if err := report.Write(file, report.FormatText, report.Report{SystemMetrics: &metrics}); err != nil {
+		return err
+	}
 
 	log.Printf("💾 Metrics saved to %s", filename)
 	return nil
@@ -368,7 +347,7 @@ func runSQLFilesCollector(ctx context.Context, cfg *config.Config, outputFile st
 	for _, f := range files {
 		fmt.Fprintf(outFile, "----- %s -----\n\n", f.Title)
 		fmt.Fprintln(outFile, f.Content)
-		fmt.Fprintln(outFile, "\n\n")
+		fmt.Fprint(outFile, "\n\n\n")
 	}
 
 	log.Printf("💾 SQL files saved to %s", outputFile)
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// Mode режим анализа: локальные правила, удалённый API или оба сразу
+type Mode string
+
+const (
+	ModeLocal  Mode = "local"
+	ModeRemote Mode = "remote"
+	ModeBoth   Mode = "both"
+)
+
+// ParseMode разбирает режим анализа из значения флага --analyzer
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return ModeRemote, nil
+	case ModeLocal, ModeRemote, ModeBoth:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown analyzer %q (expected local, remote or both)", s)
+	}
+}
+
+// New возвращает анализатор для указанного режима.
+// В режиме local удалённый клиент не используется и может быть nil.
+func New(mode Mode, remote client.Analyzer) client.Analyzer {
+	return NewWithLocal(mode, NewLocalAnalyzer(), remote)
+}
+
+// NewWithLocal возвращает анализатор для указанного режима с заранее настроенным
+// локальным анализатором
+func NewWithLocal(mode Mode, local *LocalAnalyzer, remote client.Analyzer) client.Analyzer {
+	switch mode {
+	case ModeLocal:
+		return local
+	case ModeBoth:
+		return NewCombinedAnalyzer(local, remote)
+	default:
+		return remote
+	}
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// CombinedAnalyzer объединяет результаты локальных правил и удалённого API.
+// Если API недоступен, возвращается только локальный результат.
+type CombinedAnalyzer struct {
+	local  client.Analyzer
+	remote client.Analyzer
+}
+
+// NewCombinedAnalyzer создает анализатор, использующий оба источника
+func NewCombinedAnalyzer(local, remote client.Analyzer) *CombinedAnalyzer {
+	return &CombinedAnalyzer{
+		local:  local,
+		remote: remote,
+	}
+}
+
+// AnalyzeConfig анализирует конфигурацию локально и через API
+func (a *CombinedAnalyzer) AnalyzeConfig(ctx context.Context, serverData models.ServerData, isSchedulerTask bool) (*models.Recommendation, error) {
+	local, err := a.local.AnalyzeConfig(ctx, serverData, isSchedulerTask)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := a.remote.AnalyzeConfig(ctx, serverData, isSchedulerTask)
+	if err != nil {
+		log.Printf("⚠️ Review API is unavailable, using local analysis only: %v", err)
+		return local, nil
+	}
+
+	return mergeRecommendations(local, remote), nil
+}
+
+// AnalyzeSystemMetrics анализирует метрики локально и через API
+func (a *CombinedAnalyzer) AnalyzeSystemMetrics(ctx context.Context, metrics collectors.SystemMetrics,
+	serverInfo models.ServerInfo, environment string, isSchedulerTask bool) (*models.Recommendation, error) {
+	local, err := a.local.AnalyzeSystemMetrics(ctx, metrics, serverInfo, environment, isSchedulerTask)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := a.remote.AnalyzeSystemMetrics(ctx, metrics, serverInfo, environment, isSchedulerTask)
+	if err != nil {
+		log.Printf("⚠️ Review API is unavailable, using local analysis only: %v", err)
+		return local, nil
+	}
+
+	return mergeRecommendations(local, remote), nil
+}
+
+// ReviewMigration проверяет миграцию локально и через API
+func (a *CombinedAnalyzer) ReviewMigration(ctx context.Context, migration models.MigrationReviewRequest) (*models.MigrationReviewResponse, error) {
+	local, err := a.local.ReviewMigration(ctx, migration)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := a.remote.ReviewMigration(ctx, migration)
+	if err != nil {
+		log.Printf("⚠️ Review API is unavailable, using local analysis only: %v", err)
+		return local, nil
+	}
+
+	return mergeMigrationResponses(local, remote), nil
+}
+
+// mergeRecommendations объединяет тексты рекомендаций и берёт максимальную критичность
+func mergeRecommendations(local, remote *models.Recommendation) *models.Recommendation {
+	criticality := remote.Criticality
+	localLevel, _ := gate.ParseLevel(local.Criticality)
+	remoteLevel, err := gate.ParseLevel(remote.Criticality)
+	if err != nil || localLevel > remoteLevel {
+		criticality = local.Criticality
+	}
+
+	return &models.Recommendation{
+		Content:        joinNonEmpty(remote.Content, local.Content),
+		Criticality:    criticality,
+		Recommendation: joinNonEmpty(remote.Recommendation, local.Recommendation),
+	}
+}
+
+// mergeMigrationResponses объединяет списки и берёт минимальную оценку
+func mergeMigrationResponses(local, remote *models.MigrationReviewResponse) *models.MigrationReviewResponse {
+	score := remote.Score
+	if local.Score < score {
+		score = local.Score
+	}
+
+	return &models.MigrationReviewResponse{
+		Score:           score,
+		Recommendations: append(append([]string{}, remote.Recommendations...), local.Recommendations...),
+		Issues:          append(append([]string{}, remote.Issues...), local.Issues...),
+		Warnings:        append(append([]string{}, remote.Warnings...), local.Warnings...),
+	}
+}
+
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if strings.TrimSpace(p) != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
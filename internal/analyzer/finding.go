@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// Finding результат срабатывания локального правила
+type Finding struct {
+	RuleID  string
+	Level   gate.Level
+	Subject string // Параметр, метрика или объект, к которому относится находка
+	Message string
+	Action  string
+	Line    int // Строка оператора в миграции (0, если находка не относится к строке)
+}
+
+// NewRecommendation сворачивает находки в одну рекомендацию в формате review API.
+// Критичность рекомендации равна максимальной критичности находок.
+func NewRecommendation(findings []Finding) *models.Recommendation {
+	if len(findings) == 0 {
+		return &models.Recommendation{
+			Content:     "No problems found by local rules",
+			Criticality: gate.LevelLow.String(),
+		}
+	}
+
+	level := gate.LevelLow
+	var content, actions []string
+	for _, f := range findings {
+		if f.Level > level {
+			level = f.Level
+		}
+		content = append(content, fmt.Sprintf("[%s] %s: %s", f.Level, f.Subject, f.Message))
+		if f.Action != "" {
+			actions = append(actions, fmt.Sprintf("%s: %s", f.Subject, f.Action))
+		}
+	}
+
+	return &models.Recommendation{
+		Content:        strings.Join(content, "\n"),
+		Criticality:    level.String(),
+		Recommendation: strings.Join(actions, "\n"),
+	}
+}
+
+// NewMigrationResponse переводит находки в ответ ревью миграции.
+// Находки уровня high и critical становятся проблемами, остальные — предупреждениями.
+func NewMigrationResponse(findings []Finding) *models.MigrationReviewResponse {
+	resp := &models.MigrationReviewResponse{
+		Score:           Score(findings),
+		Recommendations: []string{},
+		Issues:          []string{},
+		Warnings:        []string{},
+	}
+
+	for _, f := range findings {
+		if f.Level >= gate.LevelHigh {
+			resp.Issues = append(resp.Issues, f.text())
+		} else {
+			resp.Warnings = append(resp.Warnings, f.text())
+		}
+		if f.Action != "" {
+			resp.Recommendations = append(resp.Recommendations, fmt.Sprintf("[%s] %s", f.RuleID, f.Action))
+		}
+	}
+
+	return resp
+}
+
+// NewQueryResponse переводит находки статических правил в ответ ревью запроса.
+// В ответе нет предупреждений, поэтому все находки становятся проблемами.
+func NewQueryResponse(findings []Finding) *models.QueryReviewResponse {
+	resp := &models.QueryReviewResponse{
+		Score:           Score(findings),
+		Recommendations: []string{},
+		Issues:          []string{},
+	}
+
+	for _, f := range findings {
+		resp.Issues = append(resp.Issues, f.text())
+		if f.Action != "" {
+			resp.Recommendations = append(resp.Recommendations, fmt.Sprintf("[%s] %s", f.RuleID, f.Action))
+		}
+	}
+
+	return resp
+}
+
+// text форматирует находку как "[rule-id] line N: message"
+func (f Finding) text() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("[%s] line %d: %s", f.RuleID, f.Line, f.Message)
+	}
+	return fmt.Sprintf("[%s] %s", f.RuleID, f.Message)
+}
+
+// findingTextPattern текст находки или рекомендации локального правила: "[group/rule-id] ..."
+var findingTextPattern = regexp.MustCompile(`(?s)^\[([a-z][a-z0-9-]*/[a-z0-9][a-z0-9-]*)\] (.*)$`)
+
+// ParseFindingText выделяет идентификатор локального правила из текста проблемы, предупреждения
+// или рекомендации. Для текстов review API возвращает пустой идентификатор и текст без изменений.
+func ParseFindingText(text string) (ruleID, message string) {
+	m := findingTextPattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", text
+	}
+	return m[1], m[2]
+}
+
+// Score вычисляет оценку 0-100: каждая находка снижает оценку в зависимости от критичности
+func Score(findings []Finding) int {
+	score := 100
+	for _, f := range findings {
+		switch f.Level {
+		case gate.LevelCritical:
+			score -= 40
+		case gate.LevelHigh:
+			score -= 25
+		case gate.LevelMedium:
+			score -= 10
+		default:
+			score -= 5
+		}
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// LocalAnalyzer анализирует данные детерминированными правилами без обращения к review API
+type LocalAnalyzer struct {
+	version int                // Мажорная версия PostgreSQL для правил миграций (0 — неизвестна)
+	queries []sqlfiles.SQLFile // SQL-файлы приложения для поиска ссылок на удаляемые колонки
+	rules   []QueryRule        // Правила для SQL-файлов приложения
+}
+
+// NewLocalAnalyzer создает локальный анализатор
+func NewLocalAnalyzer() *LocalAnalyzer {
+	return &LocalAnalyzer{rules: queryRules}
+}
+
+// WithQueryRules добавляет правила для SQL-файлов приложения к встроенным
+func (a *LocalAnalyzer) WithQueryRules(rules ...QueryRule) *LocalAnalyzer {
+	a.rules = append(append([]QueryRule{}, a.rules...), rules...)
+	return a
+}
+
+// WithServerVersion задает мажорную версию PostgreSQL, на которой применяются миграции
+func (a *LocalAnalyzer) WithServerVersion(major int) *LocalAnalyzer {
+	a.version = major
+	return a
+}
+
+// WithQueries задает SQL-файлы приложения: удаление колонки, которая в них используется,
+// считается проблемой
+func (a *LocalAnalyzer) WithQueries(files []sqlfiles.SQLFile) *LocalAnalyzer {
+	a.queries = files
+	return a
+}
+
+// AnalyzeConfig проверяет параметры конфигурации PostgreSQL
+func (a *LocalAnalyzer) AnalyzeConfig(ctx context.Context, serverData models.ServerData, isSchedulerTask bool) (*models.Recommendation, error) {
+	return NewRecommendation(analyzeConfig(serverData)), nil
+}
+
+// AnalyzeSystemMetrics проверяет системные метрики сервера
+func (a *LocalAnalyzer) AnalyzeSystemMetrics(ctx context.Context, metrics collectors.SystemMetrics,
+	serverInfo models.ServerInfo, environment string, isSchedulerTask bool) (*models.Recommendation, error) {
+	return NewRecommendation(analyzeSystemMetrics(metrics)), nil
+}
+
+// ReviewMigration проверяет миграцию на опасные DDL-операции
+func (a *LocalAnalyzer) ReviewMigration(ctx context.Context, migration models.MigrationReviewRequest) (*models.MigrationReviewResponse, error) {
+	return NewMigrationResponse(analyzeMigration(migration.SQL, migration.Transactional, a.version, a.queries)), nil
+}
+
+// ReviewQuery проверяет операторы SQL-файла приложения статическими правилами.
+// Проверяются операторы, начинающиеся в строках firstLine..lastLine (весь файл, если lastLine равен 0);
+// метаданные таблиц берутся из запроса на ревью.
+func (a *LocalAnalyzer) ReviewQuery(ctx context.Context, f sqlfiles.SQLFile, query models.QueryReviewRequest, firstLine, lastLine int) (*models.QueryReviewResponse, error) {
+	return NewQueryResponse(analyzeQueries(f.Content, firstLine, lastLine, query.Tables, a.rules)), nil
+}
@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
+)
+
+// configRule проверяет конфигурацию PostgreSQL и возвращает находку, если правило сработало
+type configRule func(d models.ServerData) (Finding, bool)
+
+var configRules = []configRule{
+	checkSharedBuffersDefault,
+	checkEffectiveCacheSize,
+	checkWorkMem,
+	checkMaintenanceWorkMem,
+	checkCheckpointCompletionTarget,
+	checkMaxWalSize,
+	checkWalBuffers,
+	checkRandomPageCost,
+	checkEffectiveIOConcurrency,
+	checkDefaultStatisticsTarget,
+}
+
+// analyzeConfig применяет все правила к конфигурации
+func analyzeConfig(d models.ServerData) []Finding {
+	var findings []Finding
+	for _, rule := range configRules {
+		if f, ok := rule(d); ok {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// configBytes возвращает размер параметра памяти: нормализованный по unit из pg_settings,
+// а если метаданные не собраны — разобранный из сырого значения в нативной единице
+func configBytes(d models.ServerData, name, raw string, nativeUnit int64) (int64, bool) {
+	if size, ok := d.SettingBytes(name); ok {
+		return size, true
+	}
+	return units.ParseBytes(raw, nativeUnit)
+}
+
+func checkSharedBuffersDefault(d models.ServerData) (Finding, bool) {
+	size, ok := configBytes(d, "shared_buffers", d.Config.SharedBuffers, units.Page)
+	if !ok || size > 128*units.MB {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/shared-buffers-default",
+		Level:   gate.LevelMedium,
+		Subject: "shared_buffers",
+		Message: fmt.Sprintf("%s is the PostgreSQL default and is usually too small for a dedicated server", units.FormatBytes(size)),
+		Action:  "set shared_buffers to about 25% of server RAM",
+	}, true
+}
+
+func checkEffectiveCacheSize(d models.ServerData) (Finding, bool) {
+	cache, ok := configBytes(d, "effective_cache_size", d.Config.EffectiveCacheSize, units.Page)
+	if !ok {
+		return Finding{}, false
+	}
+	buffers, ok := configBytes(d, "shared_buffers", d.Config.SharedBuffers, units.Page)
+	if !ok || cache >= 2*buffers {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/effective-cache-size-low",
+		Level:   gate.LevelMedium,
+		Subject: "effective_cache_size",
+		Message: fmt.Sprintf("%s is less than twice shared_buffers (%s), the planner will underestimate OS cache", units.FormatBytes(cache), units.FormatBytes(buffers)),
+		Action:  "set effective_cache_size to about 75% of server RAM",
+	}, true
+}
+
+func checkWorkMem(d models.ServerData) (Finding, bool) {
+	size, ok := configBytes(d, "work_mem", d.Config.WorkMem, units.KB)
+	if !ok || size > 4*units.MB {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/work-mem-default",
+		Level:   gate.LevelLow,
+		Subject: "work_mem",
+		Message: fmt.Sprintf("%s is the PostgreSQL default, sorts and hashes may spill to disk", units.FormatBytes(size)),
+		Action:  "raise work_mem based on RAM and max_connections",
+	}, true
+}
+
+func checkMaintenanceWorkMem(d models.ServerData) (Finding, bool) {
+	size, ok := configBytes(d, "maintenance_work_mem", d.Config.MaintenanceWorkMem, units.KB)
+	if !ok || size > 64*units.MB {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/maintenance-work-mem-default",
+		Level:   gate.LevelLow,
+		Subject: "maintenance_work_mem",
+		Message: fmt.Sprintf("%s slows down VACUUM and CREATE INDEX", units.FormatBytes(size)),
+		Action:  "set maintenance_work_mem to about 5% of RAM, up to 2GB",
+	}, true
+}
+
+func checkCheckpointCompletionTarget(d models.ServerData) (Finding, bool) {
+	target, ok := units.ParseFloat(d.Config.CheckpointCompletionTarget)
+	if !ok || target >= 0.9 {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/checkpoint-completion-target-low",
+		Level:   gate.LevelMedium,
+		Subject: "checkpoint_completion_target",
+		Message: fmt.Sprintf("%.2g concentrates checkpoint I/O into short bursts", target),
+		Action:  "set checkpoint_completion_target to 0.9",
+	}, true
+}
+
+func checkMaxWalSize(d models.ServerData) (Finding, bool) {
+	size, ok := configBytes(d, "max_wal_size", d.Config.MaxWalSize, units.MB)
+	if !ok || size > units.GB {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/max-wal-size-low",
+		Level:   gate.LevelMedium,
+		Subject: "max_wal_size",
+		Message: fmt.Sprintf("%s triggers frequent checkpoints under write load", units.FormatBytes(size)),
+		Action:  "raise max_wal_size to 4GB or more for write-heavy workloads",
+	}, true
+}
+
+func checkWalBuffers(d models.ServerData) (Finding, bool) {
+	size, ok := configBytes(d, "wal_buffers", d.Config.WalBuffers, units.Page)
+	if !ok || size < 0 || size >= 16*units.MB {
+		return Finding{}, false
+	}
+	buffers, ok := configBytes(d, "shared_buffers", d.Config.SharedBuffers, units.Page)
+	if !ok || buffers < 512*units.MB {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/wal-buffers-low",
+		Level:   gate.LevelLow,
+		Subject: "wal_buffers",
+		Message: fmt.Sprintf("%s is below 16MB with large shared_buffers", units.FormatBytes(size)),
+		Action:  "set wal_buffers to 16MB or -1 (auto)",
+	}, true
+}
+
+func checkRandomPageCost(d models.ServerData) (Finding, bool) {
+	cost, ok := units.ParseFloat(d.Config.RandomPageCost)
+	if !ok || cost < 4 {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/random-page-cost-hdd",
+		Level:   gate.LevelLow,
+		Subject: "random_page_cost",
+		Message: fmt.Sprintf("%.2g assumes spinning disks and discourages index scans", cost),
+		Action:  "set random_page_cost to 1.1 on SSD or network storage",
+	}, true
+}
+
+func checkEffectiveIOConcurrency(d models.ServerData) (Finding, bool) {
+	value, ok := units.ParseFloat(d.Config.EffectiveIOConcurrency)
+	if !ok || value > 1 {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/effective-io-concurrency-low",
+		Level:   gate.LevelLow,
+		Subject: "effective_io_concurrency",
+		Message: fmt.Sprintf("%.0f disables prefetching for bitmap heap scans", value),
+		Action:  "set effective_io_concurrency to 200 on SSD storage",
+	}, true
+}
+
+func checkDefaultStatisticsTarget(d models.ServerData) (Finding, bool) {
+	value, ok := units.ParseFloat(d.Config.DefaultStatisticsTarget)
+	if !ok || value >= 100 {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "config/default-statistics-target-low",
+		Level:   gate.LevelMedium,
+		Subject: "default_statistics_target",
+		Message: fmt.Sprintf("%.0f is below the default and leads to poor row estimates", value),
+		Action:  "set default_statistics_target to at least 100",
+	}, true
+}
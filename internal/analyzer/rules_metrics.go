@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
+)
+
+// metricsRule проверяет системные метрики и возвращает находку, если правило сработало
+type metricsRule func(m collectors.SystemMetrics) (Finding, bool)
+
+var metricsRules = []metricsRule{
+	checkMemoryUsage,
+	checkDiskSpace,
+	checkSmallServer,
+}
+
+// analyzeSystemMetrics применяет все правила к системным метрикам
+func analyzeSystemMetrics(m collectors.SystemMetrics) []Finding {
+	var findings []Finding
+	for _, rule := range metricsRules {
+		if f, ok := rule(m); ok {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func checkMemoryUsage(m collectors.SystemMetrics) (Finding, bool) {
+	if m.RAMTotal == 0 {
+		return Finding{}, false
+	}
+	used := float64(m.RAMTotal-m.RAMFree) / float64(m.RAMTotal) * 100
+
+	var level gate.Level
+	switch {
+	case used >= 95:
+		level = gate.LevelCritical
+	case used >= 90:
+		level = gate.LevelHigh
+	case used >= 80:
+		level = gate.LevelMedium
+	default:
+		return Finding{}, false
+	}
+
+	return Finding{
+		RuleID:  "system/memory-pressure",
+		Level:   level,
+		Subject: "memory",
+		Message: fmt.Sprintf("%.0f%% of RAM is in use, the OS page cache is being squeezed", used),
+		Action:  "reduce shared_buffers/work_mem or add RAM",
+	}, true
+}
+
+func checkDiskSpace(m collectors.SystemMetrics) (Finding, bool) {
+	if m.DiskTotal == 0 {
+		return Finding{}, false
+	}
+	free := float64(m.DiskFree) / float64(m.DiskTotal) * 100
+
+	var level gate.Level
+	switch {
+	case free < 5:
+		level = gate.LevelCritical
+	case free < 10:
+		level = gate.LevelHigh
+	case free < 20:
+		level = gate.LevelMedium
+	default:
+		return Finding{}, false
+	}
+
+	return Finding{
+		RuleID:  "system/disk-space-low",
+		Level:   level,
+		Subject: "disk",
+		Message: fmt.Sprintf("only %.1f%% of disk space is free, WAL growth may stop the server", free),
+		Action:  "free disk space or extend the volume",
+	}, true
+}
+
+func checkSmallServer(m collectors.SystemMetrics) (Finding, bool) {
+	if m.RAMTotal == 0 || m.RAMTotal >= 2*uint64(units.GB) {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "system/low-memory",
+		Level:   gate.LevelMedium,
+		Subject: "memory",
+		Message: fmt.Sprintf("server has only %d MB of RAM", m.RAMTotal/uint64(units.MB)),
+		Action:  "allocate at least 2GB of RAM for a production PostgreSQL instance",
+	}, true
+}
@@ -0,0 +1,319 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// identPattern имя объекта (возможно, со схемой) в нормализованном SQL
+const identPattern = `(?:"[^"]*"|[a-z_][a-z0-9_$]*)(?:\.(?:"[^"]*"|[a-z_][a-z0-9_$]*))*`
+
+// Правила применяются к операторам, нормализованным sqlfiles.NormalizeQuery:
+// без комментариев, в нижнем регистре, с литералами, заменёнными на ?
+var (
+	createTableRe  = regexp.MustCompile(`^create (?:(?:global |local )?(?:temp|temporary|unlogged) )?table (?:if not exists )?(` + identPattern + `)`)
+	createIndexRe  = regexp.MustCompile(`^create (?:unique )?index (concurrently )?.*?\bon (?:only )?(` + identPattern + `)`)
+	alterTableRe   = regexp.MustCompile(`^alter table (?:if exists )?(?:only )?(` + identPattern + `)`)
+	dropTableRe    = regexp.MustCompile(`^drop table\b`)
+	dropIndexRe    = regexp.MustCompile(`^drop index (concurrently )?`)
+	lockTableRe    = regexp.MustCompile(`^lock (?:table )?`)
+	renameRe       = regexp.MustCompile(`^alter (?:table|index|view|materialized view|sequence|type|function|schema)\b.*\brename\b`)
+	concurrentlyRe = regexp.MustCompile(`\bconcurrently\b`)
+	lockTimeoutRe  = regexp.MustCompile(`^set (?:local |session )?lock_timeout\b`)
+	beginRe        = regexp.MustCompile(`^(?:begin|start transaction)\b`)
+	endRe          = regexp.MustCompile(`^(?:commit|end|rollback|abort)\b`)
+
+	addColumnRe     = regexp.MustCompile(`\badd (?:column )?(?:if not exists )?(` + identPattern + `) ([^,]*)`)
+	defaultRe       = regexp.MustCompile(`\bdefault\b`)
+	volatileRe      = regexp.MustCompile(`\bdefault \(?(?:random|gen_random_uuid|uuid_generate_v[14]|clock_timestamp|timeofday|nextval)\(`)
+	serialTypeRe    = regexp.MustCompile(`^(?:small|big)?serial\b`)
+	alterTypeRe     = regexp.MustCompile(`\balter (?:column )?(` + identPattern + `) (?:set data )?type\b`)
+	addConstraintRe = regexp.MustCompile(`\badd (?:constraint ` + identPattern + ` )?(foreign key|check|unique|primary key|exclude)\b`)
+	dropColumnRe    = regexp.MustCompile(`\bdrop column (?:if exists )?(` + identPattern + `)`)
+)
+
+// addColumnKeywords слова после ADD, которые не являются именем новой колонки
+var addColumnKeywords = map[string]bool{
+	"constraint": true, "foreign": true, "check": true, "unique": true, "primary": true, "exclude": true,
+}
+
+// migrationLinter проверяет операторы миграции по очереди, помня созданные в ней таблицы
+// и состояние транзакции и lock_timeout
+type migrationLinter struct {
+	version  int
+	queries  []sqlfiles.SQLFile
+	created  map[string]bool
+	inTx     bool
+	timeout  bool
+	ignored  map[string]bool // Правила, подавленные для текущего оператора
+	findings []Finding
+}
+
+// analyzeMigration применяет правила безопасности к каждому оператору миграции.
+// transactional — инструмент миграций сам оборачивает скрипт в транзакцию,
+// version — мажорная версия PostgreSQL (0, если неизвестна), queries — SQL-файлы приложения
+// для поиска ссылок на удаляемые колонки.
+func analyzeMigration(sql string, transactional bool, version int, queries []sqlfiles.SQLFile) []Finding {
+	l := &migrationLinter{version: version, queries: queries, created: make(map[string]bool), inTx: transactional}
+
+	stmts := sqlfiles.SplitStatements(sql)
+	ignored := suppressions(sql, stmts)
+	for i, st := range stmts {
+		if st.Kind != sqlfiles.KindSQL {
+			continue
+		}
+		l.ignored = ignored[i]
+		l.statement(st.Line, strings.TrimSuffix(sqlfiles.NormalizeQuery(st.SQL), ";"))
+	}
+
+	return l.findings
+}
+
+func (l *migrationLinter) add(line int, f Finding) {
+	if suppressed(l.ignored, f.RuleID) {
+		return
+	}
+	f.Line = line
+	l.findings = append(l.findings, f)
+}
+
+func (l *migrationLinter) statement(line int, s string) {
+	switch {
+	case beginRe.MatchString(s):
+		l.inTx = true
+		return
+	case endRe.MatchString(s):
+		l.inTx = false
+		return
+	case lockTimeoutRe.MatchString(s):
+		l.timeout = true
+		return
+	}
+
+	if m := createTableRe.FindStringSubmatch(s); m != nil {
+		l.created[tableName(m[1])] = true
+		return
+	}
+
+	if concurrentlyRe.MatchString(s) && l.inTx {
+		l.add(line, Finding{
+			RuleID:  "migration/concurrently-in-transaction",
+			Level:   gate.LevelHigh,
+			Subject: "CONCURRENTLY",
+			Message: "CONCURRENTLY cannot run inside a transaction block and the migration will fail",
+			Action:  "run the statement in a separate migration without BEGIN/COMMIT and with the tool's transaction disabled (-- +goose NO TRANSACTION, flyway executeInTransaction=false, liquibase runInTransaction:false)",
+		})
+	}
+
+	if renameRe.MatchString(s) {
+		l.add(line, Finding{
+			RuleID:  "migration/rename",
+			Level:   gate.LevelMedium,
+			Subject: "RENAME",
+			Message: "renaming breaks application code that still uses the old name during deployment",
+			Action:  "add the new object, migrate readers and writers, then drop the old one",
+		})
+	}
+
+	switch {
+	case createIndexRe.MatchString(s):
+		m := createIndexRe.FindStringSubmatch(s)
+		if m[1] == "" && !l.created[tableName(m[2])] {
+			l.add(line, Finding{
+				RuleID:  "migration/index-not-concurrent",
+				Level:   gate.LevelHigh,
+				Subject: "CREATE INDEX",
+				Message: fmt.Sprintf("CREATE INDEX on %s without CONCURRENTLY blocks writes to the table", tableName(m[2])),
+				Action:  "use CREATE INDEX CONCURRENTLY outside of a transaction",
+			})
+			l.lockTaken(line)
+		}
+
+	case dropTableRe.MatchString(s):
+		l.add(line, Finding{
+			RuleID:  "migration/drop-table",
+			Level:   gate.LevelHigh,
+			Subject: "DROP TABLE",
+			Message: "DROP TABLE irreversibly removes data",
+			Action:  "make sure the table is no longer used and a backup exists",
+		})
+		l.lockTaken(line)
+
+	case dropIndexRe.MatchString(s):
+		if dropIndexRe.FindStringSubmatch(s)[1] == "" {
+			l.lockTaken(line)
+		}
+
+	case lockTableRe.MatchString(s):
+		l.lockTaken(line)
+
+	case alterTableRe.MatchString(s):
+		table := tableName(alterTableRe.FindStringSubmatch(s)[1])
+		if l.created[table] {
+			return
+		}
+		l.alterTable(line, table, s)
+		l.lockTaken(line)
+	}
+}
+
+// lockTaken отмечает оператор, берущий тяжёлую блокировку; без lock_timeout он может
+// встать в очередь за долгой транзакцией и заблокировать все запросы к таблице
+func (l *migrationLinter) lockTaken(line int) {
+	if l.timeout {
+		return
+	}
+	// Достаточно одной находки на миграцию: lock_timeout задаётся один раз в начале
+	l.timeout = true
+	l.add(line, Finding{
+		RuleID:  "migration/missing-lock-timeout",
+		Level:   gate.LevelMedium,
+		Subject: "lock_timeout",
+		Message: "lock-heavy DDL without lock_timeout can queue behind long transactions and block all queries",
+		Action:  "SET lock_timeout = '5s' before the first DDL statement",
+	})
+}
+
+// alterTable проверяет подкоманды ALTER TABLE
+func (l *migrationLinter) alterTable(line int, table, s string) {
+	for _, m := range addColumnRe.FindAllStringSubmatch(s, -1) {
+		if addColumnKeywords[m[1]] {
+			continue
+		}
+		if f, ok := l.addColumn(table, m[1], m[2]); ok {
+			l.add(line, f)
+		}
+	}
+
+	for _, m := range alterTypeRe.FindAllStringSubmatch(s, -1) {
+		l.add(line, Finding{
+			RuleID:  "migration/column-type-change",
+			Level:   gate.LevelHigh,
+			Subject: "ALTER COLUMN TYPE",
+			Message: fmt.Sprintf("changing the type of %s.%s may rewrite the table under an ACCESS EXCLUSIVE lock", table, unquote(m[1])),
+			Action:  "add a new column, backfill it in batches and switch over",
+		})
+	}
+
+	for _, m := range addConstraintRe.FindAllStringSubmatch(s, -1) {
+		switch m[1] {
+		case "foreign key", "check":
+			if strings.Contains(s, "not valid") {
+				continue
+			}
+			l.add(line, Finding{
+				RuleID:  "migration/constraint-not-valid",
+				Level:   gate.LevelHigh,
+				Subject: "ADD CONSTRAINT",
+				Message: fmt.Sprintf("adding a %s constraint to %s validates all rows while holding a lock", strings.ToUpper(m[1]), table),
+				Action:  "add the constraint with NOT VALID and run VALIDATE CONSTRAINT in a separate statement",
+			})
+		case "unique", "primary key":
+			if strings.Contains(s, "using index") {
+				continue
+			}
+			l.add(line, Finding{
+				RuleID:  "migration/constraint-without-index",
+				Level:   gate.LevelHigh,
+				Subject: "ADD CONSTRAINT",
+				Message: fmt.Sprintf("adding a %s constraint to %s builds an index while blocking writes", strings.ToUpper(m[1]), table),
+				Action:  "create a unique index CONCURRENTLY and add the constraint with USING INDEX",
+			})
+		}
+	}
+
+	for _, m := range dropColumnRe.FindAllStringSubmatch(s, -1) {
+		l.add(line, l.dropColumn(table, unquote(m[1])))
+	}
+}
+
+// addColumn проверяет добавление колонки со значением по умолчанию: до PostgreSQL 11
+// любое DEFAULT переписывает таблицу, начиная с 11 — только волатильное
+func (l *migrationLinter) addColumn(table, column, definition string) (Finding, bool) {
+	definition = strings.TrimSpace(definition)
+	hasDefault := defaultRe.MatchString(definition)
+	notNull := strings.Contains(definition, "not null")
+	volatile := volatileRe.MatchString(definition) || serialTypeRe.MatchString(definition)
+
+	f := Finding{
+		RuleID:  "migration/add-column-default",
+		Subject: "ADD COLUMN",
+		Action:  "add the column without a default, backfill it in batches, then set DEFAULT and NOT NULL",
+	}
+	switch {
+	case volatile:
+		f.Level = gate.LevelHigh
+		f.Message = fmt.Sprintf("adding %s.%s with a volatile default rewrites the table under an ACCESS EXCLUSIVE lock", table, unquote(column))
+	case !hasDefault || (l.version >= 11):
+		return Finding{}, false
+	case l.version > 0:
+		f.Level = gate.LevelHigh
+		f.Message = fmt.Sprintf("adding %s.%s with a default rewrites the table on PostgreSQL %d", table, unquote(column), l.version)
+	case notNull:
+		f.Level = gate.LevelMedium
+		f.Message = fmt.Sprintf("adding %s.%s NOT NULL DEFAULT rewrites the table on PostgreSQL older than 11", table, unquote(column))
+	default:
+		return Finding{}, false
+	}
+	return f, true
+}
+
+// dropColumn проверяет удаление колонки; если колонка встречается в SQL-файлах приложения
+// вместе с её таблицей, находка становится проблемой
+func (l *migrationLinter) dropColumn(table, column string) Finding {
+	f := Finding{
+		RuleID:  "migration/drop-column",
+		Level:   gate.LevelMedium,
+		Subject: "DROP COLUMN",
+		Message: fmt.Sprintf("dropping %s.%s breaks application code that still reads the column", table, column),
+		Action:  "remove column usage from the application before dropping it",
+	}
+
+	if refs := columnReferences(l.queries, table, column); len(refs) > 0 {
+		f.Level = gate.LevelHigh
+		f.Message = fmt.Sprintf("%s.%s is still referenced in %s", table, column, strings.Join(refs, ", "))
+	}
+	return f
+}
+
+// columnReferences возвращает пути SQL-файлов, которые обращаются к таблице и упоминают колонку
+func columnReferences(queries []sqlfiles.SQLFile, table, column string) []string {
+	columnRe := regexp.MustCompile(`(?:^|[^a-z0-9_$"])"?` + regexp.QuoteMeta(column) + `"?(?:[^a-z0-9_$"]|$)`)
+
+	var paths []string
+	for _, q := range queries {
+		if q.IsMigration {
+			continue
+		}
+		usesTable := false
+		for _, ref := range sqlfiles.ReferencedTables(q.Content) {
+			if ref.String() == table || (ref.Schema == "" && strings.HasSuffix(table, "."+ref.Name)) {
+				usesTable = true
+				break
+			}
+		}
+		if usesTable && columnRe.MatchString(sqlfiles.NormalizeQuery(q.Content)) {
+			paths = append(paths, q.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// tableName возвращает имя объекта без кавычек, сохраняя схему
+func tableName(ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		parts[i] = unquote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func unquote(ident string) string {
+	return strings.Trim(ident, `"`)
+}
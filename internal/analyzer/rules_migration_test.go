@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+func TestMigrationRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		transactional bool
+		version       int
+		want          []string
+	}{
+		// migration/concurrently-in-transaction
+		{"concurrently without transaction", "CREATE INDEX CONCURRENTLY i ON t (a);", false, 0, nil},
+		{
+			"concurrently in explicit transaction",
+			"BEGIN;\nCREATE INDEX CONCURRENTLY i ON t (a);\nCOMMIT;",
+			false, 0,
+			[]string{"migration/concurrently-in-transaction"},
+		},
+		{
+			"concurrently after commit",
+			"BEGIN;\nCREATE TABLE x (id int);\nCOMMIT;\nCREATE INDEX CONCURRENTLY i ON t (a);",
+			false, 0, nil,
+		},
+		{
+			"concurrently in implicit transaction",
+			"CREATE INDEX CONCURRENTLY i ON t (a);",
+			true, 0,
+			[]string{"migration/concurrently-in-transaction"},
+		},
+		{
+			"drop index concurrently in implicit transaction",
+			"DROP INDEX CONCURRENTLY i;",
+			true, 0,
+			[]string{"migration/concurrently-in-transaction"},
+		},
+
+		// migration/index-not-concurrent и migration/missing-lock-timeout
+		{"index on existing table", "CREATE INDEX i ON t (a);", false, 0, []string{"migration/index-not-concurrent", "migration/missing-lock-timeout"}},
+		{"index on new table", "CREATE TABLE t (a int);\nCREATE INDEX i ON t (a);", false, 0, nil},
+		{"index with lock timeout", "SET lock_timeout = '5s';\nCREATE INDEX i ON t (a);", false, 0, []string{"migration/index-not-concurrent"}},
+
+		// migration/add-column-default
+		{"add column with default on 10", "SET lock_timeout = '5s';\nALTER TABLE t ADD COLUMN c int DEFAULT 0;", false, 10, []string{"migration/add-column-default"}},
+		{"add column with default on 11", "SET lock_timeout = '5s';\nALTER TABLE t ADD COLUMN c int DEFAULT 0;", false, 11, nil},
+		{"add column with volatile default", "SET lock_timeout = '5s';\nALTER TABLE t ADD COLUMN c uuid DEFAULT gen_random_uuid();", false, 16, []string{"migration/add-column-default"}},
+		{"add serial column", "SET lock_timeout = '5s';\nALTER TABLE t ADD COLUMN c bigserial;", false, 16, []string{"migration/add-column-default"}},
+
+		// Остальные правила
+		{"column type change", "SET lock_timeout = '5s';\nALTER TABLE t ALTER COLUMN c TYPE bigint;", false, 0, []string{"migration/column-type-change"}},
+		{"foreign key", "SET lock_timeout = '5s';\nALTER TABLE t ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES u (id);", false, 0, []string{"migration/constraint-not-valid"}},
+		{"foreign key not valid", "SET lock_timeout = '5s';\nALTER TABLE t ADD CONSTRAINT fk FOREIGN KEY (a) REFERENCES u (id) NOT VALID;", false, 0, nil},
+		{"unique constraint", "SET lock_timeout = '5s';\nALTER TABLE t ADD CONSTRAINT u UNIQUE (a);", false, 0, []string{"migration/constraint-without-index"}},
+		{"unique using index", "SET lock_timeout = '5s';\nALTER TABLE t ADD CONSTRAINT u UNIQUE USING INDEX i;", false, 0, nil},
+		{"drop column", "SET lock_timeout = '5s';\nALTER TABLE t DROP COLUMN c;", false, 0, []string{"migration/drop-column"}},
+		{"drop table", "SET lock_timeout = '5s';\nDROP TABLE t;", false, 0, []string{"migration/drop-table"}},
+		{"rename", "SET lock_timeout = '5s';\nALTER TABLE t RENAME COLUMN a TO b;", false, 0, []string{"migration/rename"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ruleIDs(analyzeMigration(tt.sql, tt.transactional, tt.version, nil))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationRulesToolTransaction(t *testing.T) {
+	const index = "CREATE INDEX CONCURRENTLY i ON t (a);"
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    bool
+	}{
+		{"goose", "001_index.sql", "-- +goose Up\n" + index, true},
+		{"goose no transaction", "001_index.sql", "-- +goose NO TRANSACTION\n-- +goose Up\n" + index, false},
+		{"flyway", "V1__index.sql", index, true},
+		{"flyway without transaction", "V1__index.sql", "-- flyway:executeInTransaction=false\n" + index, false},
+		{"liquibase", "001.sql", "-- liquibase formatted sql\n-- changeset a:1\n" + index, true},
+		{"liquibase without transaction", "001.sql", "-- liquibase formatted sql\n-- changeset a:1 runInTransaction:false\n" + index, false},
+		{"golang-migrate", "001_index.up.sql", index, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := sqlfiles.DetectMigration(tt.file, tt.content)
+			findings := analyzeMigration(tt.content, m.Transactional, 0, nil)
+			got := len(findings) == 1 && findings[0].RuleID == "migration/concurrently-in-transaction"
+			if got != tt.want || len(findings) > 1 {
+				t.Errorf("transactional=%v, findings %v, want concurrently-in-transaction=%v", m.Transactional, ruleIDs(findings), tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationRulesDropColumnReferences(t *testing.T) {
+	sql := "SET lock_timeout = '5s';\nALTER TABLE users DROP COLUMN email;"
+	queries := []sqlfiles.SQLFile{
+		{Path: "q/users.sql", Content: "SELECT id, email FROM users WHERE id = $1;"},
+		{Path: "q/orders.sql", Content: "SELECT email FROM orders;"},
+		{Path: "m/001.sql", Content: "SELECT email FROM users;", IsMigration: true},
+	}
+
+	findings := analyzeMigration(sql, false, 0, queries)
+	if len(findings) != 1 {
+		t.Fatalf("got %v, want one finding", ruleIDs(findings))
+	}
+	if f := findings[0]; f.Level != gate.LevelHigh || !strings.Contains(f.Message, "q/users.sql") || strings.Contains(f.Message, "orders") {
+		t.Errorf("got %+v, want a high finding referencing only q/users.sql", f)
+	}
+}
+
+func TestMigrationRulesSuppression(t *testing.T) {
+	sql := "SET lock_timeout = '5s';\n-- pgmon:ignore migration/drop-table\nDROP TABLE a;\nDROP TABLE b;"
+	findings := analyzeMigration(sql, false, 0, nil)
+	if len(findings) != 1 || findings[0].Line != 4 {
+		t.Errorf("got %+v, want one finding on line 4", findings)
+	}
+}
@@ -0,0 +1,381 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// QueryStatement оператор SQL-файла приложения, к которому применяются правила
+type QueryStatement struct {
+	SQL        string             // Исходный текст оператора
+	Normalized string             // Текст после sqlfiles.NormalizeQuery: литералы заменены на ?
+	Masked     string             // Текст после sqlfiles.MaskQuery: смещения совпадают с SQL
+	Tables     []models.TableInfo // Метаданные таблиц из целевой базы, если они собраны
+}
+
+// QueryRule проверяет оператор и возвращает находку, если правило сработало.
+// Дополнительные правила подключаются через LocalAnalyzer.WithQueryRules.
+type QueryRule func(st QueryStatement) (Finding, bool)
+
+var queryRules = []QueryRule{
+	checkSelectStar,
+	checkNotInSubquery,
+	checkLeadingWildcard,
+	checkLargeOffset,
+	checkFunctionOnColumn,
+	checkImplicitCrossJoin,
+	checkMissingWhere,
+	checkOrderByRandom,
+}
+
+// largeOffset значение OFFSET, начиная с которого постраничный вывод считается медленным
+const largeOffset = 1000
+
+var (
+	selectStarRe     = regexp.MustCompile(`(?:\bselect(?: distinct)?|,)(?:[a-z_][a-z0-9_$]*\.)?\*`)
+	notInSubqueryRe  = regexp.MustCompile(`\bnot in\(select (?:distinct )?([a-z_][a-z0-9_$]*(?:\.[a-z_][a-z0-9_$]*)?)`)
+	leadingWildRe    = regexp.MustCompile(`(?i)\b(?:i?like|similar\s+to)\s+[eE]?'`)
+	offsetLiteralRe  = regexp.MustCompile(`(?i)\boffset\s+(\d+)`)
+	offsetParamRe    = regexp.MustCompile(`(?i)\boffset\s+(?:\$\d+|:[a-z_]\w*|\?)`)
+	functionColumnRe = regexp.MustCompile(`(?:\bwhere|\band|\bor|\bon) ?(lower|upper|date|trim|btrim|coalesce|date_trunc|to_char|abs|round|length|md5)\((?:\?,)?([a-z_][a-z0-9_$]*(?:\.[a-z_][a-z0-9_$]*)?)(?:,[^()]*)?\) ?(?:=|<|>|!=|like\b|ilike\b|in\b|between\b|is\b)`)
+	fromRe           = regexp.MustCompile(`\bfrom\b`)
+	whereRe          = regexp.MustCompile(`\bwhere\b`)
+	missingWhereRe   = regexp.MustCompile(`^(?:update|delete from)\b`)
+	orderByRandomRe  = regexp.MustCompile(`\border by random\(\)`)
+)
+
+// fromListEnd слова, завершающие список FROM
+var fromListEnd = []string{
+	"where", "group", "order", "limit", "offset", "having", "window", "union", "except", "intersect",
+	"returning", "on", "using", "join", "inner", "left", "right", "full", "cross", "natural", "for", "fetch",
+}
+
+// analyzeQueries применяет правила к операторам SQL-файла, которые начинаются в строках
+// firstLine..lastLine (весь файл, если lastLine равен 0), с учётом комментариев pgmon:ignore
+func analyzeQueries(content string, firstLine, lastLine int, tables []models.TableInfo, rules []QueryRule) []Finding {
+	stmts := sqlfiles.SplitStatements(content)
+	ignored := suppressions(content, stmts)
+
+	var findings []Finding
+	for i, st := range stmts {
+		if st.Kind != sqlfiles.KindSQL || (lastLine > 0 && (st.Line < firstLine || st.Line > lastLine)) {
+			continue
+		}
+
+		qs := QueryStatement{
+			SQL:        st.SQL,
+			Normalized: strings.TrimSuffix(sqlfiles.NormalizeQuery(st.SQL), ";"),
+			Masked:     sqlfiles.MaskQuery(st.SQL),
+			Tables:     tables,
+		}
+		for _, rule := range rules {
+			f, ok := rule(qs)
+			if !ok || suppressed(ignored[i], f.RuleID) {
+				continue
+			}
+			f.Line = st.Line
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func checkSelectStar(st QueryStatement) (Finding, bool) {
+	for _, loc := range selectStarRe.FindAllStringIndex(st.Normalized, -1) {
+		// EXISTS (SELECT * ...) не читает колонки
+		if strings.HasSuffix(st.Normalized[:loc[0]], "exists(") {
+			continue
+		}
+		return Finding{
+			RuleID:  "query/select-star",
+			Level:   gate.LevelLow,
+			Subject: "SELECT *",
+			Message: "SELECT * reads every column, prevents index-only scans and breaks when columns change",
+			Action:  "list only the columns the application needs",
+		}, true
+	}
+	return Finding{}, false
+}
+
+func checkNotInSubquery(st QueryStatement) (Finding, bool) {
+	m := notInSubqueryRe.FindStringSubmatch(st.Normalized)
+	if m == nil {
+		return Finding{}, false
+	}
+
+	column := m[1][strings.LastIndex(m[1], ".")+1:]
+	nullable, known := columnNullable(st.Tables, column)
+	if known && !nullable {
+		return Finding{}, false
+	}
+
+	f := Finding{
+		RuleID:  "query/not-in-subquery",
+		Level:   gate.LevelMedium,
+		Subject: "NOT IN",
+		Message: fmt.Sprintf("NOT IN (SELECT %s ...) returns no rows if the subquery yields NULL and cannot use an anti-join", column),
+		Action:  "use NOT EXISTS with a correlated subquery",
+	}
+	if known {
+		f.Level = gate.LevelHigh
+		f.Message = fmt.Sprintf("NOT IN (SELECT %s ...) on a nullable column returns no rows once the subquery yields NULL", column)
+	}
+	return f, true
+}
+
+func checkLeadingWildcard(st QueryStatement) (Finding, bool) {
+	// Ключевое слово ищется в маске, а сам шаблон читается из исходного текста
+	for _, loc := range leadingWildRe.FindAllStringIndex(st.Masked, -1) {
+		if !strings.HasPrefix(st.SQL[loc[1]:], "%") {
+			continue
+		}
+		return Finding{
+			RuleID:  "query/leading-wildcard-like",
+			Level:   gate.LevelMedium,
+			Subject: "LIKE '%...'",
+			Message: "LIKE with a leading wildcard cannot use a btree index and scans the whole table",
+			Action:  "use a trigram (pg_trgm) GIN index or full-text search",
+		}, true
+	}
+	return Finding{}, false
+}
+
+func checkLargeOffset(st QueryStatement) (Finding, bool) {
+	if m := offsetLiteralRe.FindStringSubmatch(st.Masked); m != nil {
+		offset, err := strconv.Atoi(m[1])
+		if err != nil || offset < largeOffset {
+			return Finding{}, false
+		}
+		return Finding{
+			RuleID:  "query/large-offset",
+			Level:   gate.LevelMedium,
+			Subject: "OFFSET",
+			Message: fmt.Sprintf("OFFSET %d reads and discards %d rows on every page", offset, offset),
+			Action:  "use keyset pagination (WHERE id > last_seen_id ORDER BY id LIMIT n)",
+		}, true
+	}
+	if offsetParamRe.MatchString(st.Masked) {
+		return Finding{
+			RuleID:  "query/large-offset",
+			Level:   gate.LevelLow,
+			Subject: "OFFSET",
+			Message: "OFFSET pagination gets slower with every page as skipped rows are still read",
+			Action:  "use keyset pagination (WHERE id > last_seen_id ORDER BY id LIMIT n)",
+		}, true
+	}
+	return Finding{}, false
+}
+
+func checkFunctionOnColumn(st QueryStatement) (Finding, bool) {
+	for _, m := range functionColumnRe.FindAllStringSubmatch(st.Normalized, -1) {
+		fn, column := m[1], m[2][strings.LastIndex(m[2], ".")+1:]
+		if len(st.Tables) > 0 && !indexedWithoutExpression(st.Tables, fn, column) {
+			continue
+		}
+		return Finding{
+			RuleID:  "query/function-on-column",
+			Level:   gate.LevelMedium,
+			Subject: fmt.Sprintf("%s(%s)", fn, column),
+			Message: fmt.Sprintf("%s(%s) in a condition prevents using an index on %s", fn, column, column),
+			Action:  fmt.Sprintf("compare the bare column or create an expression index on %s(%s)", fn, column),
+		}, true
+	}
+	return Finding{}, false
+}
+
+func checkImplicitCrossJoin(st QueryStatement) (Finding, bool) {
+	for _, loc := range fromRe.FindAllStringIndex(st.Normalized, -1) {
+		if plainTables(fromList(st.Normalized[loc[1]:])) < 2 {
+			continue
+		}
+
+		f := Finding{
+			RuleID:  "query/implicit-cross-join",
+			Level:   gate.LevelMedium,
+			Subject: "FROM a, b",
+			Message: "tables are joined with a comma, a missing join condition silently produces a cartesian product",
+			Action:  "use explicit JOIN ... ON",
+		}
+		if !whereRe.MatchString(st.Normalized) {
+			f.Level = gate.LevelHigh
+			f.Message = "tables are joined with a comma and there is no WHERE clause: the query returns a cartesian product"
+		}
+		return f, true
+	}
+	return Finding{}, false
+}
+
+func checkMissingWhere(st QueryStatement) (Finding, bool) {
+	main := mainStatement(st.Normalized)
+	m := missingWhereRe.FindString(main)
+	// WHERE подзапроса или CTE не ограничивает изменяемые строки
+	if m == "" || hasTopLevelWord(main, "where") {
+		return Finding{}, false
+	}
+	verb := strings.ToUpper(strings.Fields(m)[0])
+	return Finding{
+		RuleID:  "query/missing-where",
+		Level:   gate.LevelHigh,
+		Subject: verb,
+		Message: fmt.Sprintf("%s without WHERE changes every row of the table", verb),
+		Action:  "add a WHERE clause or use TRUNCATE if clearing the table is intended",
+	}, true
+}
+
+func checkOrderByRandom(st QueryStatement) (Finding, bool) {
+	if !orderByRandomRe.MatchString(st.Normalized) {
+		return Finding{}, false
+	}
+	return Finding{
+		RuleID:  "query/order-by-random",
+		Level:   gate.LevelMedium,
+		Subject: "ORDER BY random()",
+		Message: "ORDER BY random() sorts the whole result to pick a few rows",
+		Action:  "use TABLESAMPLE or pick random ids within the key range",
+	}, true
+}
+
+// mainStatement возвращает основной оператор нормализованного запроса без списка WITH
+func mainStatement(s string) string {
+	if !strings.HasPrefix(s, "with ") {
+		return s
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			if end := strings.IndexByte(s[i+1:], '"'); end >= 0 {
+				i += end + 1
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth != 0 {
+				continue
+			}
+			// После списка колонок CTE идёт AS, после тела CTE — запятая или основной оператор
+			rest := strings.TrimLeft(s[i+1:], " ")
+			if strings.HasPrefix(rest, ",") || rest == "as" || strings.HasPrefix(rest, "as ") || strings.HasPrefix(rest, "as(") {
+				continue
+			}
+			return rest
+		}
+	}
+	return s
+}
+
+// hasTopLevelWord сообщает, встречается ли слово вне скобок и идентификаторов в кавычках
+func hasTopLevelWord(s, word string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			if end := strings.IndexByte(s[i+1:], '"'); end >= 0 {
+				i += end + 1
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], word) && (i == 0 || !isWordByte(s[i-1])) &&
+			(i+len(word) == len(s) || !isWordByte(s[i+len(word)])):
+			return true
+		}
+	}
+	return false
+}
+
+// fromList возвращает элементы списка FROM верхнего уровня до конца списка
+func fromList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return append(items, s[start:i])
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, s[start:i])
+				start = i + 1
+			}
+		case ' ':
+			if depth == 0 && endsFromList(s[i+1:]) {
+				return append(items, s[start:i])
+			}
+		}
+	}
+	return append(items, s[start:])
+}
+
+func endsFromList(s string) bool {
+	for _, w := range fromListEnd {
+		if strings.HasPrefix(s, w) && (len(s) == len(w) || !isWordByte(s[len(w)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// plainTables считает элементы FROM, которые являются таблицами: LATERAL, подзапросы
+// и табличные функции через запятую — обычная практика
+func plainTables(items []string) int {
+	n := 0
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || strings.HasPrefix(item, "lateral") || strings.Contains(item, "(") {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// columnNullable сообщает, допускает ли колонка NULL, по метаданным таблиц.
+// Колонка с таким именем может быть в нескольких таблицах: достаточно одной nullable.
+func columnNullable(tables []models.TableInfo, column string) (nullable, known bool) {
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			if c.Name != column {
+				continue
+			}
+			known = true
+			nullable = nullable || c.Nullable
+		}
+	}
+	return nullable, known
+}
+
+// indexedWithoutExpression сообщает, что по колонке есть индекс, но нет индекса по выражению fn(column)
+func indexedWithoutExpression(tables []models.TableInfo, fn, column string) bool {
+	columnRe := regexp.MustCompile(`\b` + regexp.QuoteMeta(column) + `\b`)
+	indexed := false
+	for _, t := range tables {
+		for _, def := range t.Indexes {
+			def = strings.ToLower(def)
+			if !columnRe.MatchString(def) {
+				continue
+			}
+			if strings.Contains(def, fn+"(") {
+				return false
+			}
+			indexed = true
+		}
+	}
+	return indexed
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
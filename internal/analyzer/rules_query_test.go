@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// ruleIDs возвращает отсортированные идентификаторы сработавших правил
+func ruleIDs(findings []Finding) []string {
+	ids := make([]string, 0, len(findings))
+	for _, f := range findings {
+		ids = append(ids, f.RuleID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestQueryRules(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		// query/missing-where
+		{"update without where", "UPDATE orders SET total = 0;", []string{"query/missing-where"}},
+		{"delete without where", "DELETE FROM orders;", []string{"query/missing-where"}},
+		{"update with where", "UPDATE orders SET total = 0 WHERE id = 1;", nil},
+		{"delete with where", "delete from orders where id = $1;", nil},
+		{
+			"where only in subquery",
+			"UPDATE orders SET total = (SELECT sum(x) FROM items WHERE items.o = orders.id);",
+			[]string{"query/missing-where"},
+		},
+		{
+			"where only in exists",
+			"DELETE FROM orders WHERE EXISTS (SELECT 1 FROM items WHERE items.o = orders.id);",
+			nil,
+		},
+		{"where in string literal", "UPDATE orders SET note = 'where id = 1';", []string{"query/missing-where"}},
+		{"where as quoted identifier", `UPDATE orders SET "where" = 1;`, []string{"query/missing-where"}},
+		{"where in comment", "DELETE FROM orders -- where id = 1\n;", []string{"query/missing-where"}},
+		{
+			"where only in cte",
+			"WITH old AS (SELECT id FROM orders WHERE created < now()) DELETE FROM orders;",
+			[]string{"query/missing-where"},
+		},
+		{
+			"cte with column list",
+			"WITH old (id) AS (SELECT id FROM orders WHERE created < now()), x AS (SELECT 1) DELETE FROM orders;",
+			[]string{"query/missing-where"},
+		},
+		{
+			"cte with where in main statement",
+			"WITH old AS (SELECT id FROM orders WHERE created < now()) DELETE FROM orders WHERE id IN (SELECT id FROM old);",
+			nil,
+		},
+		{"select for update is not an update", "WITH x AS (SELECT 1) SELECT id FROM orders WHERE id = 1 FOR UPDATE;", nil},
+
+		// Остальные правила
+		{"select star", "SELECT * FROM orders WHERE id = 1;", []string{"query/select-star"}},
+		{"exists select star", "SELECT id FROM orders o WHERE EXISTS (SELECT * FROM items i WHERE i.o = o.id);", nil},
+		{"leading wildcard", "SELECT id FROM users WHERE name LIKE '%son';", []string{"query/leading-wildcard-like"}},
+		{"trailing wildcard", "SELECT id FROM users WHERE name LIKE 'son%';", nil},
+		{"large offset", "SELECT id FROM users ORDER BY id LIMIT 10 OFFSET 5000;", []string{"query/large-offset"}},
+		{"small offset", "SELECT id FROM users ORDER BY id LIMIT 10 OFFSET 20;", nil},
+		{"wildcard literal outside like", "SELECT id FROM users WHERE name = '%son' OR name LIKE 'son%';", nil},
+		{"leading wildcard in comment", "SELECT id FROM users -- name LIKE '%son'\nWHERE id = 1;", nil},
+		{"like in string literal", "SELECT $$name LIKE '%son'$$, id FROM users WHERE id = 1;", nil},
+		{"escape string leading wildcard", "SELECT id FROM users WHERE name ILIKE E'%son';", []string{"query/leading-wildcard-like"}},
+		{"offset in comment", "SELECT id FROM users -- offset 5000\nWHERE id = 1;", nil},
+		{"offset in block comment", "SELECT id FROM users WHERE id = 1 /* OFFSET $1 */;", nil},
+		{"offset in string literal", "SELECT id FROM users WHERE note = 'offset 5000';", nil},
+		{"parameter offset", "SELECT id FROM users ORDER BY id LIMIT 10 OFFSET $1;", []string{"query/large-offset"}},
+		{"function on column", "SELECT id FROM users WHERE lower(email) = $1;", []string{"query/function-on-column"}},
+		{"implicit cross join", "SELECT a.id FROM a, b WHERE a.id = b.a_id;", []string{"query/implicit-cross-join"}},
+		{"lateral is not a cross join", "SELECT a.id FROM a, LATERAL (SELECT 1) l WHERE a.id = 1;", nil},
+		{"order by random", "SELECT id FROM users ORDER BY random() LIMIT 1;", []string{"query/order-by-random"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ruleIDs(analyzeQueries(tt.sql, 0, 0, nil, queryRules))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryRulesSuppression(t *testing.T) {
+	sql := "-- pgmon:ignore query/missing-where\nDELETE FROM sessions;\nDELETE FROM orders;"
+	findings := analyzeQueries(sql, 0, 0, nil, queryRules)
+	if len(findings) != 1 || findings[0].Line != 3 {
+		t.Errorf("got %+v, want one finding on line 3", findings)
+	}
+}
+
+func TestQueryRulesNotIn(t *testing.T) {
+	sql := "SELECT id FROM a WHERE b_id NOT IN (SELECT id FROM b);"
+	tables := []models.TableInfo{{Name: "b", Columns: []models.ColumnInfo{{Name: "id", Nullable: false}}}}
+	if got := ruleIDs(analyzeQueries(sql, 0, 0, tables, queryRules)); len(got) != 0 {
+		t.Errorf("NOT IN over a NOT NULL column: got %v, want no findings", got)
+	}
+	tables[0].Columns[0].Nullable = true
+	if got := ruleIDs(analyzeQueries(sql, 0, 0, tables, queryRules)); strings.Join(got, ",") != "query/not-in-subquery" {
+		t.Errorf("NOT IN over a nullable column: got %v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// ignorePattern комментарий подавления: -- pgmon:ignore rule-id[, rule-id ...]
+var ignorePattern = regexp.MustCompile(`--\s*pgmon:ignore\b([^\n]*)`)
+
+// ignoreAll подавляет все правила, если в комментарии не перечислены id
+const ignoreAll = "*"
+
+// suppressions возвращает подавленные правила для каждого оператора. Комментарий относится
+// к оператору, внутри которого он стоит, или к следующему за ним, если стоит перед оператором;
+// комментарий в конце строки после точки с запятой относится к оператору на этой строке.
+func suppressions(content string, stmts []sqlfiles.Statement) []map[string]bool {
+	type comment struct {
+		line  int
+		rules []string
+	}
+	var comments []comment
+	for i, line := range strings.Split(content, "\n") {
+		m := ignorePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		rules := strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' })
+		if len(rules) == 0 {
+			rules = []string{ignoreAll}
+		}
+		comments = append(comments, comment{line: i + 1, rules: rules})
+	}
+
+	out := make([]map[string]bool, len(stmts))
+	prevEnd := 0
+	for i, st := range stmts {
+		out[i] = make(map[string]bool)
+		for _, c := range comments {
+			if c.line > prevEnd && c.line <= st.EndLine {
+				for _, r := range c.rules {
+					out[i][r] = true
+				}
+			}
+		}
+		prevEnd = st.EndLine
+	}
+	return out
+}
+
+// suppressed сообщает, подавлено ли правило для оператора
+func suppressed(ignored map[string]bool, ruleID string) bool {
+	return ignored[ignoreAll] || ignored[ruleID]
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 // Config holds application configuration
@@ -13,7 +15,11 @@ type Config struct {
 
 	// Review API configuration
 	ReviewAPI struct {
-		URL string
+		URL                    string
+		Retries                int    // Attempts per request including the first one
+		BreakerThreshold       int    // Consecutive failed requests that open the circuit breaker
+		BreakerCooldownSeconds int    // How long the open breaker rejects requests
+		BreakerStateFile       string // Breaker state shared by scheduler runs (--st)
 	}
 
 	// Logging configuration
@@ -21,6 +27,27 @@ type Config struct {
 
 	// Environment
 	Environment string
+
+	// pg_settings snapshot filter (used by csi --snapshot)
+	Snapshot struct {
+		Categories []string
+		Allow      []string
+		Deny       []string
+	}
+
+	// Retention policy for stored reviews (used by history prune)
+	Retention struct {
+		Days       int
+		KeepLast   int
+		Downsample bool
+		ArchiveDir string
+	}
+
+	// Local cache of review API responses (used by csf)
+	ReviewCache struct {
+		Dir      string
+		TTLHours int
+	}
 }
 
 // Load loads configuration from environment variables
@@ -28,9 +55,16 @@ func (c *Config) Load() error {
 	// Vault configuration
 	c.VaultToken = getEnv("VAULT_TOKEN", "root")
 	c.VaultAddr = getEnv("VAULT_ADDR", "http://localhost:8200")
+	if c.VaultPath == "" {
+		c.VaultPath = getEnv("VAULT_DB_PATH", "")
+	}
 
 	// Review API
 	c.ReviewAPI.URL = getEnv("REVIEW_API_URL", "http://")
+	c.ReviewAPI.Retries = getEnvInt("REVIEW_API_RETRIES", 3)
+	c.ReviewAPI.BreakerThreshold = getEnvInt("REVIEW_API_BREAKER_THRESHOLD", 5)
+	c.ReviewAPI.BreakerCooldownSeconds = getEnvInt("REVIEW_API_BREAKER_COOLDOWN_SECONDS", 300)
+	c.ReviewAPI.BreakerStateFile = getEnv("REVIEW_API_BREAKER_STATE", "")
 
 	// Logging
 	if c.LogPath == "" {
@@ -42,6 +76,21 @@ func (c *Config) Load() error {
 		c.Environment = getEnv("ENVIRONMENT", "production")
 	}
 
+	// pg_settings snapshot filter
+	c.Snapshot.Categories = getEnvList("SETTINGS_CATEGORIES")
+	c.Snapshot.Allow = getEnvList("SETTINGS_ALLOW")
+	c.Snapshot.Deny = getEnvList("SETTINGS_DENY")
+
+	// Retention policy
+	c.Retention.Days = getEnvInt("RETENTION_DAYS", 0)
+	c.Retention.KeepLast = getEnvInt("RETENTION_KEEP_LAST", 0)
+	c.Retention.Downsample = getEnvBool("RETENTION_DOWNSAMPLE", false)
+	c.Retention.ArchiveDir = getEnv("RETENTION_ARCHIVE_DIR", "")
+
+	// Review cache
+	c.ReviewCache.Dir = getEnv("REVIEW_CACHE_DIR", "")
+	c.ReviewCache.TTLHours = getEnvInt("REVIEW_CACHE_TTL_HOURS", 7*24)
+
 	return nil
 }
 
@@ -51,3 +100,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated list from the environment
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvInt reads an integer from the environment, falling back to the default on parse errors
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvBool reads a boolean from the environment, falling back to the default on parse errors
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,214 @@
+package drift
+
+import (
+	"path"
+	"sort"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// ChangeKind тип расхождения между двумя снимками
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"   // Есть только в правом снимке
+	Removed ChangeKind = "removed" // Есть только в левом снимке
+	Changed ChangeKind = "changed" // Есть в обоих снимках с разными значениями
+)
+
+// SettingChange расхождение значения параметра конфигурации
+type SettingChange struct {
+	Name  string     `json:"name"`
+	Kind  ChangeKind `json:"kind"`
+	Left  string     `json:"left,omitempty"`
+	Right string     `json:"right,omitempty"`
+}
+
+// ExtensionChange расхождение установленного расширения или его версии
+type ExtensionChange struct {
+	Name  string     `json:"name"`
+	Kind  ChangeKind `json:"kind"`
+	Left  string     `json:"left,omitempty"`
+	Right string     `json:"right,omitempty"`
+}
+
+// Snapshot снимок сервера с именем источника (путь Vault или файл)
+type Snapshot struct {
+	Name string
+	Data models.ServerData
+}
+
+// Options параметры сравнения
+type Options struct {
+	// Ignore шаблоны имён параметров (path.Match), которые не сравниваются
+	Ignore []string
+}
+
+// Result результат сравнения двух снимков
+type Result struct {
+	Left           string            `json:"left"`
+	Right          string            `json:"right"`
+	LeftVersion    string            `json:"left_version"`
+	RightVersion   string            `json:"right_version"`
+	VersionChanged bool              `json:"version_changed"`
+	Settings       []SettingChange   `json:"settings,omitempty"`
+	Extensions     []ExtensionChange `json:"extensions,omitempty"`
+	// CommonSettingsOnly сравнивались только параметры, есть в обоих снимках:
+	// один снимок собран с --snapshot, а другой — с фиксированным списком параметров
+	CommonSettingsOnly bool `json:"common_settings_only,omitempty"`
+}
+
+// HasDrift сообщает, найдены ли расхождения
+func (r Result) HasDrift() bool {
+	return r.VersionChanged || len(r.Settings) > 0 || len(r.Extensions) > 0
+}
+
+// Compare сравнивает параметры, версию PostgreSQL и расширения двух снимков.
+// Параметры, отсутствующие в одном из снимков, попадают в added/removed. Если снимки собраны
+// в разных режимах (один с --snapshot, другой с фиксированным списком параметров models.Config),
+// сравниваются только общие параметры, иначе остальные параметры pg_settings выглядели бы дрейфом.
+func Compare(left, right Snapshot, opts Options) Result {
+	result := Result{
+		Left:         left.Name,
+		Right:        right.Name,
+		LeftVersion:  left.Data.ServerInfo.Version,
+		RightVersion: right.Data.ServerInfo.Version,
+	}
+	result.VersionChanged = result.LeftVersion != result.RightVersion
+
+	leftValues, rightValues := settingValues(left.Data), settingValues(right.Data)
+	if fixedList(leftValues) != fixedList(rightValues) {
+		leftValues, rightValues = common(leftValues, rightValues), common(rightValues, leftValues)
+		result.CommonSettingsOnly = true
+	}
+	result.Settings = compareSettings(leftValues, rightValues, opts.Ignore)
+	result.Extensions = compareExtensions(left.Data.Extensions, right.Data.Extensions)
+
+	return result
+}
+
+// settingValue значение параметра для сравнения и вывода
+type settingValue struct {
+	raw     string
+	display string
+}
+
+// settingValues возвращает значения параметров снимка. Снимки без Settings
+// (сохранённые старыми версиями) сравниваются по полям models.Config.
+func settingValues(data models.ServerData) map[string]settingValue {
+	values := make(map[string]settingValue)
+	if len(data.Settings) > 0 {
+		for name, s := range data.Settings {
+			v := settingValue{raw: s.Setting, display: s.Setting}
+			if s.Unit != "" {
+				v.display = s.Setting + " " + s.Unit
+			}
+			if s.Display != "" {
+				v.display = s.Display
+			}
+			values[name] = v
+		}
+		return values
+	}
+
+	for _, p := range data.Config.Params() {
+		if p.Value != "" {
+			values[p.Name] = settingValue{raw: p.Value, display: p.Value}
+		}
+	}
+	return values
+}
+
+// fixedList сообщает, что снимок содержит только параметры models.Config, то есть собран без --snapshot
+func fixedList(values map[string]settingValue) bool {
+	names := make(map[string]bool)
+	for _, p := range (models.Config{}).Params() {
+		names[p.Name] = true
+	}
+	for name := range values {
+		if !names[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// common возвращает параметры values, которые есть и в other
+func common(values, other map[string]settingValue) map[string]settingValue {
+	out := make(map[string]settingValue)
+	for name, v := range values {
+		if _, ok := other[name]; ok {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+func compareSettings(left, right map[string]settingValue, ignore []string) []SettingChange {
+	var changes []SettingChange
+	for name, l := range left {
+		if ignored(name, ignore) {
+			continue
+		}
+		r, ok := right[name]
+		switch {
+		case !ok:
+			changes = append(changes, SettingChange{Name: name, Kind: Removed, Left: l.display})
+		case l.raw != r.raw:
+			changes = append(changes, SettingChange{Name: name, Kind: Changed, Left: l.display, Right: r.display})
+		}
+	}
+	for name, r := range right {
+		if ignored(name, ignore) {
+			continue
+		}
+		if _, ok := left[name]; !ok {
+			changes = append(changes, SettingChange{Name: name, Kind: Added, Right: r.display})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func compareExtensions(left, right []models.Extension) []ExtensionChange {
+	leftVersions := extensionVersions(left)
+	rightVersions := extensionVersions(right)
+
+	var changes []ExtensionChange
+	for name, l := range leftVersions {
+		r, ok := rightVersions[name]
+		switch {
+		case !ok:
+			changes = append(changes, ExtensionChange{Name: name, Kind: Removed, Left: l})
+		case l != r:
+			changes = append(changes, ExtensionChange{Name: name, Kind: Changed, Left: l, Right: r})
+		}
+	}
+	for name, r := range rightVersions {
+		if _, ok := leftVersions[name]; !ok {
+			changes = append(changes, ExtensionChange{Name: name, Kind: Added, Right: r})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func extensionVersions(extensions []models.Extension) map[string]string {
+	versions := make(map[string]string, len(extensions))
+	for _, ext := range extensions {
+		versions[ext.Name] = ext.Version
+	}
+	return versions
+}
+
+// ignored сообщает, совпадает ли имя параметра с одним из шаблонов
+func ignored(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package drift
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// settings строит параметры снимка из пар имя-значение
+func settings(pairs ...string) map[string]models.Setting {
+	out := make(map[string]models.Setting)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out[pairs[i]] = models.Setting{Name: pairs[i], Setting: pairs[i+1]}
+	}
+	return out
+}
+
+func TestCompareSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		left   models.ServerData
+		right  models.ServerData
+		ignore []string
+		want   []SettingChange
+		common bool
+	}{
+		{
+			name:  "same settings",
+			left:  models.ServerData{Settings: settings("work_mem", "4096", "max_connections", "100")},
+			right: models.ServerData{Settings: settings("work_mem", "4096", "max_connections", "100")},
+		},
+		{
+			name:  "added, removed and changed",
+			left:  models.ServerData{Settings: settings("work_mem", "4096", "max_connections", "100", "jit", "on")},
+			right: models.ServerData{Settings: settings("work_mem", "8192", "max_connections", "100", "port", "5433")},
+			want: []SettingChange{
+				{Name: "jit", Kind: Removed, Left: "on"},
+				{Name: "port", Kind: Added, Right: "5433"},
+				{Name: "work_mem", Kind: Changed, Left: "4096", Right: "8192"},
+			},
+		},
+		{
+			name:   "ignore patterns",
+			left:   models.ServerData{Settings: settings("log_min_duration_statement", "100", "archive_command", "cp", "work_mem", "4096")},
+			right:  models.ServerData{Settings: settings("log_min_duration_statement", "500", "work_mem", "8192")},
+			ignore: []string{"log_*", "*_command"},
+			want:   []SettingChange{{Name: "work_mem", Kind: Changed, Left: "4096", Right: "8192"}},
+		},
+		{
+			name: "display value with units",
+			left: models.ServerData{Settings: map[string]models.Setting{
+				"shared_buffers": {Name: "shared_buffers", Setting: "16384", Unit: "8kB", Display: "128MB"},
+			}},
+			right: models.ServerData{Settings: map[string]models.Setting{
+				"shared_buffers": {Name: "shared_buffers", Setting: "32768", Unit: "8kB"},
+			}},
+			want: []SettingChange{{Name: "shared_buffers", Kind: Changed, Left: "128MB", Right: "32768 8kB"}},
+		},
+		{
+			name:  "config fields of old snapshots",
+			left:  models.ServerData{Config: models.Config{WorkMem: "4MB", RandomPageCost: "4"}},
+			right: models.ServerData{Settings: settings("work_mem", "4MB", "random_page_cost", "1.1", "max_wal_size", "1024")},
+			want: []SettingChange{
+				{Name: "max_wal_size", Kind: Added, Right: "1024"},
+				{Name: "random_page_cost", Kind: Changed, Left: "4", Right: "1.1"},
+			},
+		},
+		{
+			name:   "full snapshot against fixed list compares common settings",
+			left:   models.ServerData{Settings: settings("work_mem", "4096", "shared_buffers", "16384")},
+			right:  models.ServerData{Settings: settings("work_mem", "8192", "shared_buffers", "16384", "max_connections", "100", "jit", "on")},
+			want:   []SettingChange{{Name: "work_mem", Kind: Changed, Left: "4096", Right: "8192"}},
+			common: true,
+		},
+		{
+			name:   "fixed list against full snapshot",
+			left:   models.ServerData{Settings: settings("autovacuum", "on", "work_mem", "4096")},
+			right:  models.ServerData{Config: models.Config{WorkMem: "4096", SharedBuffers: "16384"}},
+			common: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(Snapshot{Name: "left", Data: tt.left}, Snapshot{Name: "right", Data: tt.right}, Options{Ignore: tt.ignore})
+			if !reflect.DeepEqual(got.Settings, tt.want) {
+				t.Errorf("settings:\n got %+v\nwant %+v", got.Settings, tt.want)
+			}
+			if got.CommonSettingsOnly != tt.common {
+				t.Errorf("common settings only = %v, want %v", got.CommonSettingsOnly, tt.common)
+			}
+			if got.HasDrift() != (len(tt.want) > 0) {
+				t.Errorf("HasDrift() = %v with %d changes", got.HasDrift(), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestCompareVersionAndExtensions(t *testing.T) {
+	left := models.ServerData{
+		ServerInfo: models.ServerInfo{Version: "15.4"},
+		Extensions: []models.Extension{{Name: "pg_stat_statements", Version: "1.10"}, {Name: "postgis", Version: "3.3.2"}},
+	}
+	right := models.ServerData{
+		ServerInfo: models.ServerInfo{Version: "16.1"},
+		Extensions: []models.Extension{{Name: "pg_stat_statements", Version: "1.10"}, {Name: "pgcrypto", Version: "1.3"}, {Name: "postgis", Version: "3.4.0"}},
+	}
+
+	got := Compare(Snapshot{Name: "staging", Data: left}, Snapshot{Name: "prod", Data: right}, Options{})
+	if !got.VersionChanged || got.LeftVersion != "15.4" || got.RightVersion != "16.1" {
+		t.Errorf("version change %v %s -> %s", got.VersionChanged, got.LeftVersion, got.RightVersion)
+	}
+	want := []ExtensionChange{
+		{Name: "pgcrypto", Kind: Added, Right: "1.3"},
+		{Name: "postgis", Kind: Changed, Left: "3.3.2", Right: "3.4.0"},
+	}
+	if !reflect.DeepEqual(got.Extensions, want) {
+		t.Errorf("extensions:\n got %+v\nwant %+v", got.Extensions, want)
+	}
+
+	same := Compare(Snapshot{Data: left}, Snapshot{Data: left}, Options{})
+	if same.HasDrift() {
+		t.Errorf("identical snapshots drift: %+v", same)
+	}
+}
@@ -0,0 +1,34 @@
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// LoadFile читает сохранённый снимок ServerData из JSON-файла.
+// Поддерживаются отчёт `csi --format json` (ключ server_data) и сам объект ServerData.
+func LoadFile(path string) (models.ServerData, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return models.ServerData{}, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+
+	var doc struct {
+		ServerData *models.ServerData `json:"server_data"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return models.ServerData{}, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if doc.ServerData != nil {
+		return *doc.ServerData, nil
+	}
+
+	var data models.ServerData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return models.ServerData{}, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,123 @@
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/jackc/pgx/v4"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// Explainer строит планы выполнения запросов в целевой базе.
+// Все запросы выполняются в транзакции READ ONLY со statement_timeout, которая всегда откатывается.
+type Explainer struct {
+	client  db.DB
+	analyze []string // Шаблоны файлов, для которых разрешён EXPLAIN ANALYZE
+	timeout time.Duration
+}
+
+// genericPlanVersion первая версия PostgreSQL (server_version_num) с EXPLAIN (GENERIC_PLAN)
+const genericPlanVersion = 160000
+
+// NewExplainer создает Explainer поверх подключения к целевой базе
+func NewExplainer(client db.DB) *Explainer {
+	return &Explainer{
+		client:  client,
+		timeout: 30 * time.Second,
+	}
+}
+
+// WithAnalyze разрешает EXPLAIN (ANALYZE, BUFFERS) для файлов, путь или имя которых
+// совпадает с одним из шаблонов filepath.Match
+func (e *Explainer) WithAnalyze(patterns []string) *Explainer {
+	e.analyze = patterns
+	return e
+}
+
+// WithStatementTimeout задает statement_timeout для построения плана.
+// Значения <= 0 оставляют таймаут по умолчанию: план без ограничения времени не строится.
+func (e *Explainer) WithStatementTimeout(timeout time.Duration) *Explainer {
+	if timeout > 0 {
+		e.timeout = timeout
+	}
+	return e
+}
+
+// Explain возвращает план запроса в формате JSON. С analyze запрос выполняется:
+// от изменения данных защищает транзакция READ ONLY, от долгих запросов — statement_timeout.
+// Запрос с параметрами $1, $2, ... не выполнить без значений, поэтому для него строится
+// общий план EXPLAIN (GENERIC_PLAN) без ANALYZE; до PostgreSQL 16 он недоступен.
+func (e *Explainer) Explain(ctx context.Context, sql string, analyze bool) (json.RawMessage, error) {
+	if err := CheckReadOnly(sql); err != nil {
+		return nil, fmt.Errorf("refusing to explain: %w", err)
+	}
+	generic := sqlfiles.HasParameters(sql)
+
+	tx, err := e.client.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", e.timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("failed to set statement_timeout: %w", err)
+	}
+
+	options := "FORMAT JSON"
+	switch {
+	case generic:
+		var version int
+		if err := tx.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to get server version: %w", err)
+		}
+		if version < genericPlanVersion {
+			return nil, fmt.Errorf("query has $n parameters and EXPLAIN (GENERIC_PLAN) requires PostgreSQL 16 or newer")
+		}
+		options = "GENERIC_PLAN, FORMAT JSON"
+	case analyze:
+		options = "ANALYZE, BUFFERS, FORMAT JSON"
+	}
+
+	var plan string
+	if err := tx.QueryRow(ctx, fmt.Sprintf("EXPLAIN (%s) %s", options, sql)).Scan(&plan); err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+
+	return json.RawMessage(plan), nil
+}
+
+// EnrichQuery прикладывает план к запросу на ревью. Отказ и ошибки пишутся в лог:
+// ревью выполняется и без плана.
+func (e *Explainer) EnrichQuery(ctx context.Context, f sqlfiles.SQLFile, req *models.QueryReviewRequest) {
+	analyze := e.analyzeAllowed(f.Path)
+
+	plan, err := e.Explain(ctx, req.SQL, analyze)
+	if err != nil {
+		log.Printf("⚠️ %s: skipping query plan: %v", f.Path, err)
+		return
+	}
+	if analyze && sqlfiles.HasParameters(req.SQL) {
+		log.Printf("⚠️ %s: query has $n parameters, attached a generic plan without ANALYZE", f.Path)
+	}
+
+	req.QueryPlan = plan
+}
+
+// analyzeAllowed сообщает, входит ли файл в список разрешённых для ANALYZE
+func (e *Explainer) analyzeAllowed(path string) bool {
+	for _, pattern := range e.analyze {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
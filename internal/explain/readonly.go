@@ -0,0 +1,89 @@
+package explain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// quotedIdentPattern идентификаторы в двойных кавычках: их содержимое не проверяется
+var quotedIdentPattern = regexp.MustCompile(`"[^"]*"`)
+
+// readOnlyStarts слова, с которых может начинаться читающий запрос
+var readOnlyStarts = map[string]bool{
+	"select": true, "with": true, "values": true, "table": true,
+}
+
+// tokenPattern слова и отдельные символы нормализованного запроса
+var tokenPattern = regexp.MustCompile(`[a-z0-9_$]+|[^a-z0-9_$\s]`)
+
+// modifyingVerbs операторы, которые могут стоять в CTE или подзапросе читающего запроса.
+// Остальные команды (DDL, COPY, SET, ANALYZE и т.д.) допустимы только в начале оператора,
+// а его начало уже ограничено readOnlyStarts, поэтому те же слова в роли колонок не мешают.
+var modifyingVerbs = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true,
+}
+
+// lockingClauses блокирующие варианты FOR в конце SELECT
+var lockingClauses = [][]string{
+	{"for", "update"}, {"for", "no", "key", "update"}, {"for", "share"}, {"for", "key", "share"},
+}
+
+// CheckReadOnly отсеивает операторы, для которых план строить не нужно: DML, DDL, SELECT INTO,
+// блокировки и несколько операторов. Это не гарантия безопасности: функции с побочными эффектами
+// (pg_terminate_backend, nextval, setval, dblink_exec) проверку проходят. Изменения в целевой базе
+// запрещает транзакция READ ONLY, а время выполнения ограничивает statement_timeout;
+// действия вне транзакции (pg_terminate_backend, dblink_exec) они не останавливают,
+// поэтому ANALYZE разрешается только для доверенных файлов.
+func CheckReadOnly(sql string) error {
+	normalized := strings.TrimSpace(sqlfiles.NormalizeQuery(sql))
+	normalized = strings.TrimRight(normalized, ";")
+	if normalized == "" {
+		return fmt.Errorf("empty statement")
+	}
+	if strings.Contains(normalized, ";") {
+		return fmt.Errorf("multiple statements")
+	}
+
+	tokens := tokenPattern.FindAllString(quotedIdentPattern.ReplaceAllString(normalized, " "), -1)
+	if len(tokens) == 0 || !readOnlyStarts[tokens[0]] {
+		return fmt.Errorf("not a read-only query")
+	}
+	for i, tok := range tokens {
+		// INTO зарезервировано и вне INSERT встречается только в SELECT INTO
+		if tok == "into" {
+			return fmt.Errorf("statement contains INTO")
+		}
+		// Изменяющий CTE или подзапрос: WITH d AS (DELETE FROM t ...)
+		if modifyingVerbs[tok] && i > 0 && tokens[i-1] == "(" && i+1 < len(tokens) && isWord(tokens[i+1]) {
+			return fmt.Errorf("statement contains %s", strings.ToUpper(tok))
+		}
+		for _, clause := range lockingClauses {
+			if hasTokens(tokens[i:], clause) {
+				return fmt.Errorf("statement contains %s", strings.ToUpper(strings.Join(clause, " ")))
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasTokens сообщает, начинается ли tokens с последовательности want
+func hasTokens(tokens, want []string) bool {
+	if len(tokens) < len(want) {
+		return false
+	}
+	for i, w := range want {
+		if tokens[i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// isWord сообщает, является ли токен словом: литералы после нормализации заменены на ?
+func isWord(tok string) bool {
+	return tok[0] == '_' || (tok[0] >= 'a' && tok[0] <= 'z')
+}
@@ -0,0 +1,46 @@
+package explain
+
+import (
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		sql string
+		ok  bool
+	}{
+		{"SELECT id FROM users WHERE id = $1;", true},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", true},
+		{"VALUES (1), (2)", true},
+		{`SELECT "update" FROM t`, true},
+		{"SELECT 'delete from t'", true},
+		// Имена колонок, совпадающие с командами, не мешают
+		{"SELECT comment FROM posts", true},
+		{"SELECT id, set, analyze, do, lock, copy FROM t WHERE comment IS NOT NULL", true},
+		{"SELECT coalesce(update, 0), lower(comment) FROM t ORDER BY create", true},
+		{"SELECT substring(name FROM 1 FOR 3) FROM t", true},
+		{"", false},
+		{"-- comment only", false},
+		{"SELECT 1; SELECT 2", false},
+		{"UPDATE t SET a = 1", false},
+		{"WITH d AS (DELETE FROM t RETURNING id) SELECT * FROM d", false},
+		{"SELECT * INTO t2 FROM t", false},
+		{"SELECT * FROM t FOR UPDATE", false},
+		{"SELECT * FROM t FOR NO KEY UPDATE", false},
+		{"SELECT * FROM t FOR SHARE SKIP LOCKED", false},
+		{"WITH i AS (INSERT INTO t VALUES (1) RETURNING id) SELECT * FROM i", false},
+		{`WITH u AS MATERIALIZED (UPDATE "T" SET a = 1 RETURNING id) SELECT * FROM u`, false},
+		{"SELECT * FROM (DELETE FROM t RETURNING id) d", false},
+		{"SET work_mem = '64MB'", false},
+		{"ANALYZE t", false},
+		{"EXPLAIN ANALYZE SELECT 1", false},
+		// Функции с побочными эффектами проверку проходят: от них защищает только транзакция
+		{"SELECT pg_terminate_backend(pid) FROM pg_stat_activity", true},
+		{"SELECT nextval('s')", true},
+	}
+	for _, tt := range tests {
+		if err := CheckReadOnly(tt.sql); (err == nil) != tt.ok {
+			t.Errorf("CheckReadOnly(%q) = %v, want ok=%v", tt.sql, err, tt.ok)
+		}
+	}
+}
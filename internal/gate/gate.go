@@ -0,0 +1,164 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// Level уровень критичности результата ревью
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelLow
+	LevelMedium
+	LevelHigh
+	LevelCritical
+)
+
+// String возвращает имя уровня критичности
+func (l Level) String() string {
+	switch l {
+	case LevelLow:
+		return "low"
+	case LevelMedium:
+		return "medium"
+	case LevelHigh:
+		return "high"
+	case LevelCritical:
+		return "critical"
+	default:
+		return "none"
+	}
+}
+
+// ParseLevel разбирает уровень критичности из строки (в том числе из models.Recommendation.Criticality)
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low", "info":
+		return LevelLow, nil
+	case "medium", "moderate", "warning":
+		return LevelMedium, nil
+	case "high", "error":
+		return LevelHigh, nil
+	case "critical":
+		return LevelCritical, nil
+	default:
+		return LevelNone, fmt.Errorf("unknown criticality level %q", s)
+	}
+}
+
+// LevelFromScore вычисляет уровень критичности по оценке ревью (0-100); у оценки 100 критичности нет
+func LevelFromScore(score int) Level {
+	switch {
+	case score >= 100:
+		return LevelNone
+	case score >= 80:
+		return LevelLow
+	case score >= 60:
+		return LevelMedium
+	case score >= 40:
+		return LevelHigh
+	default:
+		return LevelCritical
+	}
+}
+
+// ResultLevel вычисляет уровень критичности результата ревью. Результат без проблем и
+// предупреждений критичности не имеет, какой бы ни была оценка.
+func ResultLevel(r client.FileResult) Level {
+	if len(r.Issues) == 0 && len(r.Warnings) == 0 {
+		return LevelNone
+	}
+	return LevelFromScore(r.Score)
+}
+
+// Policy условия, при которых ревью считается проваленным
+type Policy struct {
+	MinScore       int   // Минимальная допустимая оценка (0 — не проверять)
+	FailOnIssues   bool  // Провал при наличии хотя бы одной проблемы
+	FailOnWarnings bool  // Провал при наличии хотя бы одного предупреждения
+	FailOnLevel    Level // Провал при критичности не ниже указанной (LevelNone — не проверять)
+}
+
+// ParsePolicy собирает политику из значений флагов --min-score и --fail-on
+func ParsePolicy(minScore int, failOn []string) (Policy, error) {
+	if minScore < 0 || minScore > 100 {
+		return Policy{}, fmt.Errorf("min score must be in range 0-100, got %d", minScore)
+	}
+
+	policy := Policy{MinScore: minScore}
+	for _, item := range failOn {
+		item = strings.ToLower(strings.TrimSpace(item))
+		switch item {
+		case "":
+			continue
+		case "issues":
+			policy.FailOnIssues = true
+		case "warnings":
+			policy.FailOnWarnings = true
+		default:
+			level, err := ParseLevel(item)
+			if err != nil {
+				return Policy{}, fmt.Errorf("invalid --fail-on value: %w", err)
+			}
+			if policy.FailOnLevel == LevelNone || level < policy.FailOnLevel {
+				policy.FailOnLevel = level
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// Enabled сообщает, задано ли хотя бы одно условие
+func (p Policy) Enabled() bool {
+	return p.MinScore > 0 || p.FailOnIssues || p.FailOnWarnings || p.FailOnLevel != LevelNone
+}
+
+// Violation нарушение политики для конкретного файла
+type Violation struct {
+	Path   string
+	Reason string
+}
+
+// Evaluate проверяет результаты ревью. Файлы, которые не удалось отправить, не оцениваются.
+func (p Policy) Evaluate(results []client.FileResult) []Violation {
+	var violations []Violation
+	for _, r := range results {
+		if r.Failed() {
+			continue
+		}
+
+		if p.MinScore > 0 && r.Score < p.MinScore {
+			violations = append(violations, Violation{
+				Path:   r.Location(),
+				Reason: fmt.Sprintf("score %d is below minimum %d", r.Score, p.MinScore),
+			})
+		}
+		if p.FailOnIssues && len(r.Issues) > 0 {
+			violations = append(violations, Violation{
+				Path:   r.Location(),
+				Reason: fmt.Sprintf("%d issue(s) found", len(r.Issues)),
+			})
+		}
+		if p.FailOnWarnings && len(r.Warnings) > 0 {
+			violations = append(violations, Violation{
+				Path:   r.Location(),
+				Reason: fmt.Sprintf("%d warning(s) found", len(r.Warnings)),
+			})
+		}
+		if p.FailOnLevel != LevelNone {
+			if level := ResultLevel(r); level >= p.FailOnLevel {
+				violations = append(violations, Violation{
+					Path:   r.Location(),
+					Reason: fmt.Sprintf("criticality %s is at or above %s", level, p.FailOnLevel),
+				})
+			}
+		}
+	}
+
+	return violations
+}
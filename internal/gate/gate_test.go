@@ -0,0 +1,107 @@
+package gate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		minScore int
+		failOn   []string
+		want     Policy
+		wantErr  bool
+	}{
+		{"empty", 0, nil, Policy{}, false},
+		{"min score", 70, []string{""}, Policy{MinScore: 70}, false},
+		{"issues and warnings", 0, []string{"issues", " Warnings "}, Policy{FailOnIssues: true, FailOnWarnings: true}, false},
+		{"level", 0, []string{"high"}, Policy{FailOnLevel: LevelHigh}, false},
+		{"lowest level wins", 0, []string{"critical", "medium", "high"}, Policy{FailOnLevel: LevelMedium}, false},
+		{"level alias", 0, []string{"warning"}, Policy{FailOnLevel: LevelMedium}, false},
+		{"unknown value", 0, []string{"severe"}, Policy{}, true},
+		{"negative min score", -1, nil, Policy{}, true},
+		{"min score over 100", 101, nil, Policy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy(tt.minScore, tt.failOn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if got.Enabled() != (tt.want != Policy{}) {
+				t.Errorf("Enabled() = %v for %+v", got.Enabled(), got)
+			}
+		})
+	}
+}
+
+func TestLevelFromScore(t *testing.T) {
+	tests := []struct {
+		score int
+		want  Level
+	}{
+		{100, LevelNone},
+		{99, LevelLow},
+		{80, LevelLow},
+		{79, LevelMedium},
+		{60, LevelMedium},
+		{59, LevelHigh},
+		{40, LevelHigh},
+		{39, LevelCritical},
+		{0, LevelCritical},
+	}
+	for _, tt := range tests {
+		if got := LevelFromScore(tt.score); got != tt.want {
+			t.Errorf("LevelFromScore(%d) = %s, want %s", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	clean := client.FileResult{Path: "clean.sql", Score: 85}
+	perfect := client.FileResult{Path: "perfect.sql", Score: 100, Issues: []string{"nit"}}
+	low := client.FileResult{Path: "low.sql", Score: 80, Issues: []string{"nit"}}
+	medium := client.FileResult{Path: "medium.sql", Score: 60, Warnings: []string{"lock"}}
+	high := client.FileResult{Path: "high.sql", Score: 40, Issues: []string{"seq scan"}}
+	critical := client.FileResult{Path: "critical.sql", Score: 39, Issues: []string{"drop"}}
+	failed := client.FileResult{Path: "failed.sql", Err: errors.New("unreachable")}
+	all := []client.FileResult{clean, perfect, low, medium, high, critical, failed}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{"fail on low skips clean and perfect results", Policy{FailOnLevel: LevelLow}, []string{"low.sql", "medium.sql", "high.sql", "critical.sql"}},
+		{"fail on medium", Policy{FailOnLevel: LevelMedium}, []string{"medium.sql", "high.sql", "critical.sql"}},
+		{"fail on high", Policy{FailOnLevel: LevelHigh}, []string{"high.sql", "critical.sql"}},
+		{"fail on critical", Policy{FailOnLevel: LevelCritical}, []string{"critical.sql"}},
+		{"min score", Policy{MinScore: 60}, []string{"high.sql", "critical.sql"}},
+		{"issues", Policy{FailOnIssues: true}, []string{"perfect.sql", "low.sql", "high.sql", "critical.sql"}},
+		{"warnings", Policy{FailOnWarnings: true}, []string{"medium.sql"}},
+		{"disabled", Policy{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range tt.policy.Evaluate(all) {
+				if v.Reason == "" {
+					t.Errorf("%s: violation without a reason", v.Path)
+				}
+				got = append(got, v.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got violations %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
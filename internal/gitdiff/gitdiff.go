@@ -0,0 +1,159 @@
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Status вид изменения файла
+type Status string
+
+const (
+	Added    Status = "added"
+	Modified Status = "modified"
+)
+
+// LineRange диапазон строк [Start, End] новой версии файла, нумерация с 1
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// File файл, добавленный или изменённый относительно базовой ревизии
+type File struct {
+	Path   string // Абсолютный путь
+	Status Status
+	Lines  []LineRange // Изменённые строки; для добавленных файлов не заполняется
+}
+
+// hunkPattern заголовок блока изменений: @@ -a,b +c,d @@
+var hunkPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Changed возвращает файлы в каталоге dir, совпадающие с pathspec-шаблонами, которые добавлены
+// или изменены относительно merge-base ревизии base и HEAD. Учитываются незакоммиченные
+// и неотслеживаемые файлы; удалённые файлы и переименования без изменений пропускаются.
+func Changed(ctx context.Context, dir, base string, patterns ...string) ([]File, error) {
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find git repository: %w", err)
+	}
+	top = strings.TrimSpace(top)
+
+	mergeBase, err := git(ctx, dir, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find merge base of %s and HEAD: %w", base, err)
+	}
+
+	args := append([]string{"diff", "-U0", "--no-color", "--no-ext-diff", "-M", "--diff-filter=AMR", strings.TrimSpace(mergeBase), "--"}, patterns...)
+	diff, err := git(ctx, dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+	files := parseDiff(top, diff)
+
+	args = append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "--"}, patterns...)
+	untracked, err := git(ctx, dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	for _, path := range strings.Split(strings.TrimSpace(untracked), "\n") {
+		if path != "" {
+			files = append(files, File{Path: filepath.Join(top, unquote(path)), Status: Added})
+		}
+	}
+
+	return files, nil
+}
+
+// Overlaps сообщает, пересекает ли диапазон строк [start, end] изменённые строки
+func Overlaps(lines []LineRange, start, end int) bool {
+	for _, r := range lines {
+		if r.Start <= end && start <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDiff разбирает вывод git diff -U0: пути файлов и диапазоны изменённых строк
+func parseDiff(top, diff string) []File {
+	var files []File
+	var current *File
+	added := false
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current, added = nil, false
+		case strings.HasPrefix(line, "new file mode"):
+			added = true
+		case strings.HasPrefix(line, "+++ "):
+			path := strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				continue
+			}
+			path = strings.TrimPrefix(unquote(path), "b/")
+			status := Modified
+			if added {
+				status = Added
+			}
+			files = append(files, File{Path: filepath.Join(top, path), Status: status})
+			current = &files[len(files)-1]
+		case current != nil && current.Status == Modified:
+			m := hunkPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			// Удаление без добавленных строк затрагивает оператор вокруг строки start
+			if count == 0 {
+				if start < 1 {
+					start = 1
+				}
+				count = 1
+			}
+			current.Lines = append(current.Lines, LineRange{Start: start, End: start + count - 1})
+		}
+	}
+
+	return files
+}
+
+// unquote снимает кавычки, которыми git обрамляет пути со спецсимволами
+func unquote(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if s, err := strconv.Unquote(path); err == nil {
+			return s
+		}
+	}
+	return path
+}
+
+// git выполняет команду git в каталоге dir и возвращает stdout
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
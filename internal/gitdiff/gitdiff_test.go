@@ -0,0 +1,162 @@
+package gitdiff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	diff := `diff --git a/q/report.sql b/q/report.sql
+index 1111111..2222222 100644
+--- a/q/report.sql
++++ b/q/report.sql
+@@ -3 +3 @@ select
+-  a
++  b
+@@ -10,0 +11,2 @@ from t
++where x
++and y
+@@ -20,3 +22,0 @@ order by
+-  c
+-  d
+-  e
+diff --git a/q/new.sql b/q/new.sql
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/q/new.sql
+@@ -0,0 +1,2 @@
++select 1;
++select 2;
+diff --git a/q/old.sql b/q/moved.sql
+similarity index 90%
+rename from q/old.sql
+rename to q/moved.sql
+index 4444444..5555555 100644
+--- a/q/old.sql
++++ b/q/moved.sql
+@@ -1 +1 @@
+-select 1;
++select 10;
+diff --git a/q/same.sql b/q/renamed.sql
+similarity index 100%
+rename from q/same.sql
+rename to q/renamed.sql
+diff --git a/q/gone.sql b/q/gone.sql
+deleted file mode 100644
+index 6666666..0000000
+--- a/q/gone.sql
++++ /dev/null
+@@ -1 +0,0 @@
+-select 1;
+diff --git "a/q/\321\204.sql" "b/q/\321\204.sql"
+index 7777777..8888888 100644
+--- "a/q/\321\204.sql"
++++ "b/q/\321\204.sql"
+@@ -0,0 +1 @@
++select 1;
+`
+
+	got := parseDiff("/repo", diff)
+	want := []File{
+		{Path: "/repo/q/report.sql", Status: Modified, Lines: []LineRange{{3, 3}, {11, 12}, {22, 22}}},
+		{Path: "/repo/q/new.sql", Status: Added},
+		{Path: "/repo/q/moved.sql", Status: Modified, Lines: []LineRange{{1, 1}}},
+		{Path: "/repo/q/ф.sql", Status: Modified, Lines: []LineRange{{1, 1}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDiff:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	lines := []LineRange{{3, 3}, {10, 12}}
+	tests := []struct {
+		start, end int
+		want       bool
+	}{
+		{1, 2, false},
+		{1, 3, true},
+		{3, 3, true},
+		{4, 9, false},
+		{12, 20, true},
+		{13, 20, false},
+		{5, 15, true},
+	}
+	for _, tt := range tests {
+		if got := Overlaps(lines, tt.start, tt.end); got != tt.want {
+			t.Errorf("Overlaps(%d, %d) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+	if Overlaps(nil, 1, 100) {
+		t.Error("no changed lines should not overlap")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("a.sql", "select 1;\nselect 2;\nselect 3;\n")
+	write("b.sql", "select 1;\n")
+	write("c.sql", "select 1;\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+	run("checkout", "-q", "-b", "feature")
+
+	write("a.sql", "select 1;\nselect 20;\nselect 3;\n")
+	run("rm", "-q", "b.sql")
+	write("d.sql", "select 4;\n")
+	run("add", "d.sql")
+	run("commit", "-q", "-m", "change")
+	write("e.sql", "select 5;\n")   // Неотслеживаемый
+	write("notes.txt", "not sql\n") // Не совпадает с шаблоном
+
+	files, err := Changed(context.Background(), dir, "main", "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	top, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]File)
+	for _, f := range files {
+		rel, _ := filepath.Rel(top, f.Path)
+		got[rel] = f
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %v, want a.sql, d.sql and e.sql", got)
+	}
+	if f := got["a.sql"]; f.Status != Modified || !reflect.DeepEqual(f.Lines, []LineRange{{2, 2}}) {
+		t.Errorf("a.sql: %+v", f)
+	}
+	if f := got["d.sql"]; f.Status != Added {
+		t.Errorf("d.sql: %+v", f)
+	}
+	if f := got["e.sql"]; f.Status != Added {
+		t.Errorf("e.sql: %+v", f)
+	}
+}
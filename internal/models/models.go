@@ -24,9 +24,17 @@ type ServerInfo struct {
 
 // ServerData represents the overall server configuration and info
 type ServerData struct {
-	Config      Config     `json:"config"`
-	Environment string     `json:"environment"`
-	ServerInfo  ServerInfo `json:"server_info"`
+	Config      Config             `json:"config"`
+	Settings    map[string]Setting `json:"settings,omitempty"`
+	Extensions  []Extension        `json:"extensions,omitempty"`
+	Environment string             `json:"environment"`
+	ServerInfo  ServerInfo         `json:"server_info"`
+}
+
+// Extension represents an installed PostgreSQL extension
+type Extension struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
 }
 
 // Recommendation represents a configuration recommendation message
@@ -55,7 +63,10 @@ type BatchReviewRequest struct {
 // MigrationReviewRequest represents a migration SQL script for review
 type MigrationReviewRequest struct {
 	SQL         string `json:"sql"`
+	RollbackSQL string `json:"rollback_sql,omitempty"`
 	Environment string `json:"environment,omitempty"`
+	// Transactional is set when the migration tool runs the script in a transaction
+	Transactional bool `json:"transactional,omitempty"`
 }
 
 // QueryReviewResponse represents the response for a single query review
@@ -78,10 +89,72 @@ type MigrationReviewResponse struct {
 	Warnings        []string `json:"warnings"`
 }
 
-// TableInfo represents information about a database table
+// TableInfo represents information about a database table.
+// RowCount is the planner estimate (pg_class.reltuples), Indexes hold index definitions.
 type TableInfo struct {
-	Name     string   `json:"name"`
-	Schema   string   `json:"schema,omitempty"`
-	RowCount int64    `json:"row_count,omitempty"`
-	Indexes  []string `json:"indexes,omitempty"`
+	Name      string       `json:"name"`
+	Schema    string       `json:"schema,omitempty"`
+	RowCount  int64        `json:"row_count,omitempty"`
+	Indexes   []string     `json:"indexes,omitempty"`
+	Columns   []ColumnInfo `json:"columns,omitempty"`
+	SizeBytes int64        `json:"size_bytes,omitempty"`
+	SeqScan   int64        `json:"seq_scan,omitempty"`
+	IdxScan   int64        `json:"idx_scan,omitempty"`
+}
+
+// ColumnInfo represents a table column with its data type
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Setting represents a single pg_settings row with unit metadata.
+// Setting keeps the raw value, Bytes and Milliseconds hold it normalized by Unit.
+type Setting struct {
+	Name           string   `json:"name"`
+	Setting        string   `json:"setting"`
+	Unit           string   `json:"unit,omitempty"`
+	VarType        string   `json:"vartype"`
+	Category       string   `json:"category,omitempty"`
+	MinVal         string   `json:"min_val,omitempty"`
+	MaxVal         string   `json:"max_val,omitempty"`
+	BootVal        string   `json:"boot_val,omitempty"`
+	ResetVal       string   `json:"reset_val,omitempty"`
+	Source         string   `json:"source,omitempty"`
+	PendingRestart bool     `json:"pending_restart"`
+	Bytes          *int64   `json:"bytes,omitempty"`
+	Milliseconds   *float64 `json:"milliseconds,omitempty"`
+	Display        string   `json:"display,omitempty"`
+}
+
+// SettingBytes returns the normalized size of a memory setting if unit metadata was collected
+func (d ServerData) SettingBytes(name string) (int64, bool) {
+	if s, ok := d.Settings[name]; ok && s.Bytes != nil {
+		return *s.Bytes, true
+	}
+	return 0, false
+}
+
+// ConfigParam is a named value of the legacy Config struct
+type ConfigParam struct {
+	Name  string
+	Value string
+}
+
+// Params returns Config values keyed by pg_settings names in declaration order
+func (c Config) Params() []ConfigParam {
+	return []ConfigParam{
+		{"shared_buffers", c.SharedBuffers},
+		{"effective_cache_size", c.EffectiveCacheSize},
+		{"maintenance_work_mem", c.MaintenanceWorkMem},
+		{"checkpoint_completion_target", c.CheckpointCompletionTarget},
+		{"wal_buffers", c.WalBuffers},
+		{"default_statistics_target", c.DefaultStatisticsTarget},
+		{"random_page_cost", c.RandomPageCost},
+		{"effective_io_concurrency", c.EffectiveIOConcurrency},
+		{"work_mem", c.WorkMem},
+		{"min_wal_size", c.MinWalSize},
+		{"max_wal_size", c.MaxWalSize},
+	}
 }
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+)
+
+// writeCSV выводит историю ревью в CSV: тренды, если они есть, иначе список ревью
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+
+	if len(r.Trends) > 0 {
+		cw.Write([]string{"fingerprint", "path", "reviews", "first", "last", "min", "max", "delta", "first_at", "last_at", "sql"})
+		for _, t := range r.Trends {
+			cw.Write([]string{
+				t.Fingerprint, t.Path, strconv.Itoa(t.Reviews),
+				strconv.Itoa(t.First), strconv.Itoa(t.Last), strconv.Itoa(t.Min), strconv.Itoa(t.Max), strconv.Itoa(t.Delta),
+				t.Points[0].CreatedAt.Format(time.RFC3339), t.Points[len(t.Points)-1].CreatedAt.Format(time.RFC3339),
+				t.SQL,
+			})
+		}
+	} else {
+		cw.Write([]string{"id", "created_at", "source_database", "environment", "path", "fingerprint", "score",
+			"issues", "warnings", "recommendations", "errors", "sql"})
+		for _, rec := range r.Reviews {
+			cw.Write([]string{
+				strconv.FormatInt(rec.ID, 10), rec.CreatedAt.Format(time.RFC3339), rec.SourceDatabase, rec.Environment,
+				rec.Path, rec.Fingerprint, recordScore(rec),
+				strings.Join(rec.Issues, "\n"), strings.Join(rec.Warnings, "\n"),
+				strings.Join(rec.Recommendations, "\n"), strings.Join(rec.Errors, "\n"),
+				rec.SQL,
+			})
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	return nil
+}
+
+// recordScore оценка сохранённого ревью или пустая строка, если ревью не удалось
+func recordScore(rec storage.Record) string {
+	if rec.Score == nil {
+		return ""
+	}
+	return strconv.Itoa(*rec.Score)
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnit записывает отчёт JUnit XML: один testcase на файл.
+// Файл с проблемами считается упавшим тестом, недоступность API — ошибкой теста.
+func writeJUnit(w io.Writer, results []client.FileResult) error {
+	suites := map[string]*junitTestSuite{
+		"query":     {Name: "pgmon.queries"},
+		"migration": {Name: "pgmon.migrations"},
+	}
+
+	for _, r := range results {
+		suite := suites[fileKind(r)]
+		name := r.Title
+		if r.Statement > 0 {
+			name = fmt.Sprintf("%s #%d", r.Title, r.Statement)
+		}
+		tc := junitTestCase{
+			Name:      name,
+			ClassName: suite.Name,
+			File:      artifactURI(r.Path),
+			Line:      r.StartLine,
+		}
+
+		switch {
+		case r.Failed():
+			tc.Error = &junitFailure{Message: "review failed", Type: "ReviewError", Body: r.Err.Error()}
+			suite.Errors++
+		case len(r.Issues) > 0:
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d issue(s), score %d", len(r.Issues), r.Score),
+				Type:    "ReviewIssues",
+				Body:    strings.Join(r.Issues, "\n"),
+			}
+			suite.Failures++
+		}
+
+		tc.SystemOut = junitSystemOut(r)
+		suite.TestCases = append(suite.TestCases, tc)
+		suite.Tests++
+	}
+
+	out := junitTestSuites{Name: toolName}
+	for _, key := range []string{"query", "migration"} {
+		suite := suites[key]
+		if suite.Tests == 0 {
+			continue
+		}
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Errors += suite.Errors
+		out.Suites = append(out.Suites, *suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSystemOut собирает оценку, предупреждения и рекомендации в system-out
+func junitSystemOut(r client.FileResult) string {
+	if r.Failed() {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "score: %d\n", r.Score)
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "warning: %s\n", warning)
+	}
+	for _, rec := range r.Recommendations {
+		fmt.Fprintf(&b, "recommendation: %s\n", rec)
+	}
+	return b.String()
+}
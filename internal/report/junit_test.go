@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFileResults(&buf, FormatJUnit, reviewResults); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Error("report has no XML header")
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, buf.String())
+	}
+	if got.Name != toolName || got.Tests != 4 || got.Failures != 1 || got.Errors != 1 {
+		t.Errorf("testsuites %s: %d tests, %d failures, %d errors, want %s: 4, 1, 1", got.Name, got.Tests, got.Failures, got.Errors, toolName)
+	}
+	if len(got.Suites) != 2 {
+		t.Fatalf("got %d suites, want queries and migrations", len(got.Suites))
+	}
+
+	queries, migrations := got.Suites[0], got.Suites[1]
+	if queries.Name != "pgmon.queries" || queries.Tests != 3 || queries.Failures != 1 || queries.Errors != 1 {
+		t.Errorf("queries suite %+v", queries)
+	}
+	if migrations.Name != "pgmon.migrations" || migrations.Tests != 1 || migrations.Failures != 0 || migrations.Errors != 0 {
+		t.Errorf("migrations suite %+v", migrations)
+	}
+
+	users := queries.TestCases[0]
+	if users.Name != "users.sql #2" || users.File != "sql/users.sql" || users.Line != 3 || users.ClassName != "pgmon.queries" {
+		t.Errorf("statement testcase %+v", users)
+	}
+	if users.Failure == nil || users.Failure.Message != "2 issue(s), score 55" ||
+		!strings.Contains(users.Failure.Body, "Missing index on users.email") {
+		t.Errorf("statement failure %+v", users.Failure)
+	}
+	if !strings.Contains(users.SystemOut, "score: 55") || !strings.Contains(users.SystemOut, "recommendation: [query/select-star]") {
+		t.Errorf("statement system-out %q", users.SystemOut)
+	}
+
+	if clean := queries.TestCases[1]; clean.Failure != nil || clean.Error != nil {
+		t.Errorf("clean file failed: %+v", clean)
+	}
+	if broken := queries.TestCases[2]; broken.Error == nil || broken.Error.Type != "ReviewError" || broken.SystemOut != "" {
+		t.Errorf("failed review testcase %+v", broken)
+	}
+
+	// Предупреждения миграции не роняют тест, а попадают в system-out
+	migration := migrations.TestCases[0]
+	if migration.Failure != nil || !strings.Contains(migration.SystemOut, "warning: [migration/missing-lock-timeout]") {
+		t.Errorf("migration testcase %+v", migration)
+	}
+}
@@ -0,0 +1,202 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/drift"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/tuning"
+)
+
+// writeMarkdown выводит отчёт в Markdown, удобном для артефактов CI и комментариев к PR
+func writeMarkdown(w io.Writer, r Report) {
+	if r.ServerData != nil {
+		writeServerDataMarkdown(w, *r.ServerData)
+	}
+	if r.ServerInfo != nil {
+		writeServerInfoMarkdown(w, *r.ServerInfo)
+	}
+	if r.SystemMetrics != nil {
+		writeSystemMetricsMarkdown(w, *r.SystemMetrics)
+	}
+	if r.Recommendation != nil {
+		writeRecommendationMarkdown(w, *r.Recommendation)
+	}
+	if len(r.Tuning) > 0 {
+		writeTuningMarkdown(w, r.Tuning)
+	}
+	if r.Drift != nil {
+		writeDriftMarkdown(w, *r.Drift)
+	}
+	if len(r.Reviews) > 0 {
+		writeReviewsMarkdown(w, r.Reviews)
+	}
+	if len(r.Trends) > 0 {
+		writeTrendsMarkdown(w, r.Trends)
+	}
+	if len(r.Files) > 0 {
+		writeFileResultsMarkdown(w, r.Files)
+	}
+}
+
+func writeServerInfoMarkdown(w io.Writer, info models.ServerInfo) {
+	fmt.Fprintf(w, "## PostgreSQL Server Information\n\n")
+	fmt.Fprintf(w, "| Field | Value |\n|---|---|\n")
+	fmt.Fprintf(w, "| Version | %s |\n", mdCell(info.Version))
+	fmt.Fprintf(w, "| Host | %s |\n", mdCell(info.Host))
+	fmt.Fprintf(w, "| Database | %s |\n\n", mdCell(info.Database))
+}
+
+func writeServerDataMarkdown(w io.Writer, data models.ServerData) {
+	writeServerInfoMarkdown(w, data.ServerInfo)
+	fmt.Fprintf(w, "**Environment:** %s\n\n", mdCell(data.Environment))
+	fmt.Fprintf(w, "### Configuration\n\n")
+	if len(data.Settings) > 0 {
+		fmt.Fprintf(w, "| Parameter | Setting | Unit | Value | Source | Pending restart |\n|---|---|---|---|---|---|\n")
+		for _, st := range sortedSettings(data.Settings) {
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s | %v |\n",
+				st.Name, mdCell(st.Setting), st.Unit, mdCell(settingValue(st)), st.Source, st.PendingRestart)
+		}
+	} else {
+		fmt.Fprintf(w, "| Parameter | Value |\n|---|---|\n")
+		for _, p := range data.Config.Params() {
+			fmt.Fprintf(w, "| `%s` | %s |\n", p.Name, mdCell(p.Value))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func writeSystemMetricsMarkdown(w io.Writer, m collectors.SystemMetrics) {
+	fmt.Fprintf(w, "## System Metrics\n\n")
+	fmt.Fprintf(w, "Collected at %s\n\n", m.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(w, "| CPU cores | %d |\n", m.CPUCores)
+	fmt.Fprintf(w, "| CPU load ratio | %.1f%% |\n", m.CPULoad)
+	fmt.Fprintf(w, "| Total RAM | %d MB |\n", m.RAMTotal/1024/1024)
+	fmt.Fprintf(w, "| Used RAM | %d MB |\n", m.RAMUsed/1024/1024)
+	fmt.Fprintf(w, "| Free RAM | %d MB |\n", m.RAMFree/1024/1024)
+	fmt.Fprintf(w, "| Total disk | %d GB |\n", m.DiskTotal/1024/1024/1024)
+	fmt.Fprintf(w, "| Used disk | %d GB |\n", m.DiskUsed/1024/1024/1024)
+	fmt.Fprintf(w, "| Free disk | %d GB |\n", m.DiskFree/1024/1024/1024)
+	fmt.Fprintf(w, "| Goroutines | %d |\n", m.Goroutines)
+	fmt.Fprintf(w, "| Heap alloc | %d MB |\n\n", m.HeapAlloc/1024/1024)
+}
+
+func writeRecommendationMarkdown(w io.Writer, rec models.Recommendation) {
+	fmt.Fprintf(w, "## Recommendation\n\n")
+	fmt.Fprintf(w, "**Criticality:** %s\n\n", mdCell(rec.Criticality))
+	fmt.Fprintf(w, "%s\n\n", rec.Content)
+	if rec.Recommendation != "" {
+		fmt.Fprintf(w, "> %s\n\n", strings.ReplaceAll(rec.Recommendation, "\n", "\n> "))
+	}
+}
+
+func writeTuningMarkdown(w io.Writer, changes []tuning.Change) {
+	fmt.Fprintf(w, "## Configuration Tuning\n\n")
+	fmt.Fprintf(w, "| Parameter | Current | Recommended | Unit | Rationale |\n|---|---|---|---|---|\n")
+	for _, c := range changes {
+		recommended := mdCell(c.Recommended)
+		if c.Changed {
+			recommended = "**" + recommended + "**"
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s |\n", c.Parameter, mdCell(c.Current), recommended, c.Unit, mdCell(c.Rationale))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeDriftMarkdown(w io.Writer, d drift.Result) {
+	fmt.Fprintf(w, "## Configuration Drift\n\n")
+	fmt.Fprintf(w, "`%s` → `%s`\n\n", d.Left, d.Right)
+
+	if d.CommonSettingsOnly {
+		fmt.Fprintf(w, "> Only settings present in both snapshots are compared: they were collected in different modes\n\n")
+	}
+
+	if !d.HasDrift() {
+		fmt.Fprintf(w, "✅ No drift detected\n\n")
+		return
+	}
+
+	if d.VersionChanged {
+		fmt.Fprintf(w, "| Version | Value |\n|---|---|\n")
+		fmt.Fprintf(w, "| Left | %s |\n| Right | %s |\n\n", mdCell(d.LeftVersion), mdCell(d.RightVersion))
+	}
+	if len(d.Settings) > 0 {
+		fmt.Fprintf(w, "| Setting | Change | Left | Right |\n|---|---|---|---|\n")
+		for _, c := range d.Settings {
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n", c.Name, c.Kind, mdCell(c.Left), mdCell(c.Right))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.Extensions) > 0 {
+		fmt.Fprintf(w, "| Extension | Change | Left | Right |\n|---|---|---|---|\n")
+		for _, c := range d.Extensions {
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n", c.Name, c.Kind, mdCell(c.Left), mdCell(c.Right))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeReviewsMarkdown(w io.Writer, records []storage.Record) {
+	fmt.Fprintf(w, "## Review History\n\n")
+	fmt.Fprintf(w, "| ID | Created | Source | Environment | Path | Fingerprint | Score | Issues |\n|---|---|---|---|---|---|---|---|\n")
+	for _, rec := range records {
+		score := recordScore(rec)
+		if score == "" {
+			score = "❌"
+		}
+		fmt.Fprintf(w, "| %d | %s | %s | %s | `%s` | `%s` | %s | %d |\n", rec.ID, rec.CreatedAt.Format("2006-01-02 15:04"),
+			mdCell(rec.SourceDatabase), mdCell(rec.Environment), rec.Path, rec.Fingerprint, score, len(rec.Issues))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeTrendsMarkdown(w io.Writer, trends []storage.Trend) {
+	fmt.Fprintf(w, "## Score Trends\n\n")
+	fmt.Fprintf(w, "| Fingerprint | Path | Reviews | First | Last | Min | Max | Delta |\n|---|---|---|---|---|---|---|---|\n")
+	for _, t := range trends {
+		fmt.Fprintf(w, "| `%s` | `%s` | %d | %d | %d | %d | %d | %+d |\n", t.Fingerprint, t.Path, t.Reviews, t.First, t.Last, t.Min, t.Max, t.Delta)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeFileResultsMarkdown(w io.Writer, results []client.FileResult) {
+	fmt.Fprintf(w, "## SQL Review\n\n")
+	fmt.Fprintf(w, "| File | Type | Score | Issues | Warnings | Status |\n|---|---|---|---|---|---|\n")
+	for _, r := range results {
+		status, score := "✅", fmt.Sprintf("%d", r.Score)
+		if r.Failed() {
+			status, score = "❌", "-"
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s | %d | %d | %s |\n", r.Location(), fileKind(r), score, len(r.Issues), len(r.Warnings), status)
+	}
+	fmt.Fprintln(w)
+
+	for _, r := range results {
+		if !r.Failed() && len(r.Issues) == 0 && len(r.Warnings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "### `%s`\n\n", r.Location())
+		if r.Failed() {
+			fmt.Fprintf(w, "- ❌ %s\n", r.Err)
+		}
+		for _, issue := range r.Issues {
+			fmt.Fprintf(w, "- ❗ %s\n", issue)
+		}
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(w, "- ⚠️ %s\n", warning)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// mdCell экранирует значение для ячейки Markdown-таблицы
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/drift"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/tuning"
+)
+
+// Format формат вывода результатов команд
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatMarkdown Format = "markdown"
+	FormatSARIF    Format = "sarif"
+	FormatJUnit    Format = "junit"
+	FormatCSV      Format = "csv"
+)
+
+// ParseFormat разбирает формат вывода из значения флага --format
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatText, nil
+	case "yml":
+		return FormatYAML, nil
+	case "md":
+		return FormatMarkdown, nil
+	case FormatText, FormatJSON, FormatYAML, FormatMarkdown, FormatSARIF, FormatJUnit, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (expected text, json, yaml, markdown, sarif, junit or csv)", s)
+	}
+}
+
+// Report данные, собранные командой, для вывода. Пустые секции не выводятся.
+type Report struct {
+	ServerData     *models.ServerData        `json:"server_data,omitempty"`
+	ServerInfo     *models.ServerInfo        `json:"server_info,omitempty"`
+	SystemMetrics  *collectors.SystemMetrics `json:"system_metrics,omitempty"`
+	Recommendation *models.Recommendation    `json:"recommendation,omitempty"`
+	Tuning         []tuning.Change           `json:"tuning,omitempty"`
+	Drift          *drift.Result             `json:"drift,omitempty"`
+	Reviews        []storage.Record          `json:"reviews,omitempty"`
+	Trends         []storage.Trend           `json:"trends,omitempty"`
+	Files          []client.FileResult       `json:"-"`
+}
+
+// hasServerSections сообщает, есть ли в отчёте данные о сервере, метриках или конфигурации
+func (r Report) hasServerSections() bool {
+	return r.ServerData != nil || r.ServerInfo != nil || r.SystemMetrics != nil ||
+		r.Recommendation != nil || len(r.Tuning) > 0 || r.Drift != nil
+}
+
+// hasHistory сообщает, есть ли в отчёте сохранённые ревью или тренды оценок
+func (r Report) hasHistory() bool {
+	return len(r.Reviews) > 0 || len(r.Trends) > 0
+}
+
+// document представление Report для JSON и YAML с текстом ошибок ревью
+type document struct {
+	Report
+	Files []fileResultJSON `json:"files,omitempty"`
+}
+
+// fileResultJSON JSON-представление результата с текстом ошибки
+type fileResultJSON struct {
+	client.FileResult
+	Error string `json:"error,omitempty"`
+}
+
+func newDocument(r Report) document {
+	doc := document{Report: r}
+	for _, f := range r.Files {
+		item := fileResultJSON{FileResult: f}
+		if f.Failed() {
+			item.Error = f.Err.Error()
+		}
+		doc.Files = append(doc.Files, item)
+	}
+	return doc
+}
+
+// Write записывает отчёт в указанном формате.
+// Форматы sarif и junit поддерживаются только для результатов ревью SQL-файлов,
+// csv — только для истории ревью.
+func Write(w io.Writer, format Format, r Report) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, newDocument(r))
+	case FormatYAML:
+		return writeYAML(w, newDocument(r))
+	case FormatMarkdown:
+		writeMarkdown(w, r)
+		return nil
+	case FormatCSV:
+		if r.hasServerSections() || len(r.Files) > 0 {
+			return fmt.Errorf("format %s is only supported for review history", format)
+		}
+		return writeCSV(w, r)
+	case FormatSARIF, FormatJUnit:
+		if r.hasServerSections() || r.hasHistory() {
+			return fmt.Errorf("format %s is only supported for SQL file reviews", format)
+		}
+		if format == FormatSARIF {
+			return writeSARIF(w, r.Files)
+		}
+		return writeJUnit(w, r.Files)
+	default:
+		writeText(w, r)
+		return nil
+	}
+}
+
+// WriteFileResults записывает результаты ревью SQL-файлов в указанном формате
+func WriteFileResults(w io.Writer, format Format, results []client.FileResult) error {
+	return Write(w, format, Report{Files: results})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
+
+// artifactURI приводит путь файла к относительному URI со слешами
+func artifactURI(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// fileKind возвращает тип файла для отчётов
+func fileKind(r client.FileResult) string {
+	if r.IsMigration {
+		return "migration"
+	}
+	return "query"
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"io"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/analyzer"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gate"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "pgmon"
+	toolURI      = "https://github.com/ratmirtech/postgresql-query-monitor"
+)
+
+// Идентификаторы правил SARIF для результатов удалённого ревью. Находки локальных правил
+// (migration/..., query/...) выводятся под идентификаторами этих правил.
+const (
+	RuleReviewIssue          = "pgmon/review-issue"
+	RuleReviewWarning        = "pgmon/review-warning"
+	RuleReviewRecommendation = "pgmon/review-recommendation"
+	RuleReviewFailed         = "pgmon/review-failed"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+var sarifRules = []sarifRule{
+	{ID: RuleReviewIssue, ShortDescription: sarifMessage{Text: "Issue reported by SQL review"}},
+	{ID: RuleReviewWarning, ShortDescription: sarifMessage{Text: "Warning reported by migration review"}},
+	{ID: RuleReviewRecommendation, ShortDescription: sarifMessage{Text: "Recommendation from SQL review"}},
+	{ID: RuleReviewFailed, ShortDescription: sarifMessage{Text: "SQL file could not be reviewed"}},
+}
+
+// sarifLevel переводит уровень критичности в уровень SARIF
+func sarifLevel(level gate.Level) string {
+	switch level {
+	case gate.LevelHigh, gate.LevelCritical:
+		return "error"
+	case gate.LevelMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func writeSARIF(w io.Writer, results []client.FileResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          append([]sarifRule{}, sarifRules...),
+		}},
+		Results: []sarifResult{},
+	}
+
+	registered := map[string]bool{}
+	for _, rule := range sarifRules {
+		registered[rule.ID] = true
+	}
+	for _, r := range results {
+		for _, res := range fileSARIFResults(r) {
+			// Правила локального анализа регистрируются в порядке первого срабатывания
+			if !registered[res.RuleID] {
+				registered[res.RuleID] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+					ID:               res.RuleID,
+					ShortDescription: sarifMessage{Text: "Local analysis rule " + res.RuleID},
+				})
+			}
+			run.Results = append(run.Results, res)
+		}
+	}
+
+	return writeJSON(w, sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// fileSARIFResults строит результаты SARIF для одного файла
+func fileSARIFResults(r client.FileResult) []sarifResult {
+	line := r.StartLine
+	if line < 1 {
+		line = 1
+	}
+	locations := []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: artifactURI(r.Path)},
+		Region:           sarifRegion{StartLine: line, EndLine: r.EndLine},
+	}}}
+
+	if r.Failed() {
+		return []sarifResult{{
+			RuleID:    RuleReviewFailed,
+			Level:     "error",
+			Message:   sarifMessage{Text: r.Err.Error()},
+			Locations: locations,
+		}}
+	}
+
+	level := gate.ResultLevel(r)
+	properties := map[string]interface{}{
+		"score":       r.Score,
+		"criticality": level.String(),
+		"kind":        fileKind(r),
+	}
+
+	var out []sarifResult
+	add := func(ruleID, sarifLvl, text string) {
+		// Находка локального правила выводится под идентификатором этого правила
+		if localRule, message := analyzer.ParseFindingText(text); localRule != "" {
+			ruleID, text = localRule, message
+		}
+		out = append(out, sarifResult{
+			RuleID:     ruleID,
+			Level:      sarifLvl,
+			Message:    sarifMessage{Text: text},
+			Locations:  locations,
+			Properties: properties,
+		})
+	}
+
+	for _, issue := range r.Issues {
+		add(RuleReviewIssue, sarifLevel(level), issue)
+	}
+	for _, warning := range r.Warnings {
+		add(RuleReviewWarning, "warning", warning)
+	}
+	for _, rec := range r.Recommendations {
+		add(RuleReviewRecommendation, "note", rec)
+	}
+
+	return out
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// reviewResults результаты ревью с находками review API, локальных правил и ошибкой
+var reviewResults = []client.FileResult{
+	{
+		Title:           "users.sql",
+		Path:            "sql/users.sql",
+		StartLine:       3,
+		EndLine:         5,
+		Statement:       2,
+		Score:           55,
+		Issues:          []string{"Missing index on users.email", "[query/select-star] SELECT * returns all columns"},
+		Recommendations: []string{"[query/select-star] List the columns explicitly"},
+	},
+	{
+		Title:       "001_init.up.sql",
+		Path:        "db/migrations/001_init.up.sql",
+		IsMigration: true,
+		Score:       90,
+		Warnings:    []string{"[migration/missing-lock-timeout] lock_timeout is not set"},
+	},
+	{
+		Title: "clean.sql",
+		Path:  "sql/clean.sql",
+		Score: 100,
+	},
+	{
+		Title: "broken.sql",
+		Path:  "sql/broken.sql",
+		Err:   errors.New("review API is unavailable"),
+	},
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFileResults(&buf, FormatSARIF, reviewResults); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != sarifVersion || log.Schema != sarifSchema || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version %q, schema %q, %d runs", log.Version, log.Schema, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != toolName {
+		t.Errorf("driver name %q, want %q", run.Tool.Driver.Name, toolName)
+	}
+
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("rule %s has no description", rule.ID)
+		}
+		rules = append(rules, rule.ID)
+	}
+	wantRules := []string{
+		RuleReviewIssue, RuleReviewWarning, RuleReviewRecommendation, RuleReviewFailed,
+		"query/select-star", "migration/missing-lock-timeout",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("rules %q, want %q", rules, wantRules)
+	}
+
+	type result struct {
+		rule, level, text, uri string
+		startLine, endLine     int
+	}
+	want := []result{
+		{RuleReviewIssue, "error", "Missing index on users.email", "sql/users.sql", 3, 5},
+		{"query/select-star", "error", "SELECT * returns all columns", "sql/users.sql", 3, 5},
+		{"query/select-star", "note", "List the columns explicitly", "sql/users.sql", 3, 5},
+		{"migration/missing-lock-timeout", "warning", "lock_timeout is not set", "db/migrations/001_init.up.sql", 1, 0},
+		{RuleReviewFailed, "error", "review API is unavailable", "sql/broken.sql", 1, 0},
+	}
+	var got []result
+	for _, r := range run.Results {
+		if len(r.Locations) != 1 {
+			t.Fatalf("result %s has %d locations, want 1", r.RuleID, len(r.Locations))
+		}
+		loc := r.Locations[0].PhysicalLocation
+		got = append(got, result{r.RuleID, r.Level, r.Message.Text, loc.ArtifactLocation.URI, loc.Region.StartLine, loc.Region.EndLine})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results:\n got %+v\nwant %+v", got, want)
+	}
+
+	if props := run.Results[0].Properties; props["criticality"] != "high" || props["kind"] != "query" || props["score"] != float64(55) {
+		t.Errorf("properties %v, want high criticality, kind query and score 55", props)
+	}
+}
+
+func TestWriteSARIFWithoutResults(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFileResults(&buf, FormatSARIF, nil); err != nil {
+		t.Fatal(err)
+	}
+	// GitHub code scanning требует массив results даже без находок
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("empty run has no results array:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,251 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/drift"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/storage"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/tuning"
+)
+
+// writeText выводит отчёт в человекочитаемом виде
+func writeText(w io.Writer, r Report) {
+	sections := 0
+	section := func() {
+		if sections > 0 {
+			fmt.Fprintln(w)
+		}
+		sections++
+	}
+
+	if r.ServerData != nil {
+		section()
+		writeServerDataText(w, *r.ServerData)
+	}
+	if r.ServerInfo != nil {
+		section()
+		writeServerInfoText(w, *r.ServerInfo)
+	}
+	if r.SystemMetrics != nil {
+		section()
+		writeSystemMetricsText(w, *r.SystemMetrics)
+	}
+	if r.Recommendation != nil {
+		section()
+		writeRecommendationText(w, *r.Recommendation)
+	}
+	if len(r.Tuning) > 0 {
+		section()
+		writeTuningText(w, r.Tuning)
+	}
+	if r.Drift != nil {
+		section()
+		writeDriftText(w, *r.Drift)
+	}
+	if len(r.Reviews) > 0 {
+		section()
+		writeReviewsText(w, r.Reviews)
+	}
+	if len(r.Trends) > 0 {
+		section()
+		writeTrendsText(w, r.Trends)
+	}
+	if len(r.Files) > 0 {
+		section()
+		writeFileResultsText(w, r.Files)
+	}
+}
+
+func writeServerInfoText(w io.Writer, info models.ServerInfo) {
+	fmt.Fprintf(w, "PostgreSQL Server Information\n")
+	fmt.Fprintf(w, "============================\n\n")
+	fmt.Fprintf(w, "Version:  %s\n", info.Version)
+	fmt.Fprintf(w, "Host:     %s\n", info.Host)
+	fmt.Fprintf(w, "Database: %s\n", info.Database)
+}
+
+func writeServerDataText(w io.Writer, data models.ServerData) {
+	writeServerInfoText(w, data.ServerInfo)
+	fmt.Fprintf(w, "Environment: %s\n\n", data.Environment)
+
+	fmt.Fprintf(w, "Configuration:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(data.Settings) > 0 {
+		fmt.Fprintln(tw, "  NAME\tSETTING\tUNIT\tVALUE\tSOURCE\tPENDING RESTART")
+		for _, st := range sortedSettings(data.Settings) {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%v\n", st.Name, st.Setting, st.Unit, settingValue(st), st.Source, st.PendingRestart)
+		}
+	} else {
+		for _, p := range data.Config.Params() {
+			fmt.Fprintf(tw, "  %s\t%s\n", p.Name, p.Value)
+		}
+	}
+	tw.Flush()
+}
+
+func writeSystemMetricsText(w io.Writer, metrics collectors.SystemMetrics) {
+	fmt.Fprintf(w, "System Metrics Report\n")
+	fmt.Fprintf(w, "====================\n\n")
+	fmt.Fprintf(w, "Timestamp: %s\n\n", metrics.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "CPU Information:\n")
+	fmt.Fprintf(w, "  Cores: %d\n", metrics.CPUCores)
+	fmt.Fprintf(w, "  Load Ratio: %.1f%%\n\n", metrics.CPULoad)
+	fmt.Fprintf(w, "Memory Information:\n")
+	fmt.Fprintf(w, "  Total RAM: %d MB\n", metrics.RAMTotal/1024/1024)
+	fmt.Fprintf(w, "  Used RAM: %d MB\n", metrics.RAMUsed/1024/1024)
+	fmt.Fprintf(w, "  Free RAM: %d MB\n\n", metrics.RAMFree/1024/1024)
+	fmt.Fprintf(w, "Disk Information:\n")
+	fmt.Fprintf(w, "  Total Disk: %d GB\n", metrics.DiskTotal/1024/1024/1024)
+	fmt.Fprintf(w, "  Used Disk: %d GB\n", metrics.DiskUsed/1024/1024/1024)
+	fmt.Fprintf(w, "  Free Disk: %d GB\n\n", metrics.DiskFree/1024/1024/1024)
+	fmt.Fprintf(w, "Go Runtime Information:\n")
+	fmt.Fprintf(w, "  Goroutines: %d\n", metrics.Goroutines)
+	fmt.Fprintf(w, "  GC Pauses: %d ns\n", metrics.GCPauses)
+	fmt.Fprintf(w, "  Heap Alloc: %d MB\n", metrics.HeapAlloc/1024/1024)
+	fmt.Fprintf(w, "  Heap Sys: %d MB\n", metrics.HeapSys/1024/1024)
+	fmt.Fprintf(w, "  Stack In Use: %d KB\n", metrics.StackInUse/1024)
+}
+
+func writeRecommendationText(w io.Writer, rec models.Recommendation) {
+	fmt.Fprintf(w, "Recommendation\n")
+	fmt.Fprintf(w, "==============\n\n")
+	fmt.Fprintf(w, "Criticality: %s\n", rec.Criticality)
+	fmt.Fprintf(w, "Content: %s\n", rec.Content)
+	fmt.Fprintf(w, "Recommendation: %s\n", rec.Recommendation)
+}
+
+func writeTuningText(w io.Writer, changes []tuning.Change) {
+	fmt.Fprintf(w, "Configuration Tuning\n")
+	fmt.Fprintf(w, "====================\n\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PARAMETER\tCURRENT\tRECOMMENDED\tUNIT\t\tRATIONALE")
+	for _, c := range changes {
+		mark := ""
+		if c.Changed {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Parameter, c.Current, c.Recommended, c.Unit, mark, c.Rationale)
+	}
+	tw.Flush()
+}
+
+func writeDriftText(w io.Writer, d drift.Result) {
+	fmt.Fprintf(w, "Configuration Drift: %s -> %s\n", d.Left, d.Right)
+	fmt.Fprintf(w, "==================================\n\n")
+
+	if d.CommonSettingsOnly {
+		fmt.Fprintf(w, "Only settings present in both snapshots are compared: they were collected in different modes\n\n")
+	}
+	if !d.HasDrift() {
+		fmt.Fprintln(w, "No drift detected")
+		return
+	}
+
+	if d.VersionChanged {
+		fmt.Fprintf(w, "Version:\n  - %s\n  + %s\n\n", d.LeftVersion, d.RightVersion)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(d.Settings) > 0 {
+		fmt.Fprintln(tw, "SETTING\tCHANGE\tLEFT\tRIGHT")
+		for _, c := range d.Settings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, c.Kind, c.Left, c.Right)
+		}
+		fmt.Fprintln(tw)
+	}
+	if len(d.Extensions) > 0 {
+		fmt.Fprintln(tw, "EXTENSION\tCHANGE\tLEFT\tRIGHT")
+		for _, c := range d.Extensions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, c.Kind, c.Left, c.Right)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "Settings: %d, extensions: %d\n", len(d.Settings), len(d.Extensions))
+}
+
+func writeReviewsText(w io.Writer, records []storage.Record) {
+	fmt.Fprintf(w, "Review History\n")
+	fmt.Fprintf(w, "==============\n\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tSOURCE\tENV\tPATH\tFINGERPRINT\tSCORE\tISSUES")
+	for _, rec := range records {
+		score := recordScore(rec)
+		if score == "" {
+			score = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", rec.ID, rec.CreatedAt.Format("2006-01-02 15:04"),
+			rec.SourceDatabase, rec.Environment, rec.Path, rec.Fingerprint, score, len(rec.Issues))
+	}
+	tw.Flush()
+}
+
+func writeTrendsText(w io.Writer, trends []storage.Trend) {
+	fmt.Fprintf(w, "Score Trends\n")
+	fmt.Fprintf(w, "============\n\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FINGERPRINT\tPATH\tREVIEWS\tFIRST\tLAST\tMIN\tMAX\tDELTA")
+	for _, t := range trends {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%+d\n", t.Fingerprint, t.Path, t.Reviews, t.First, t.Last, t.Min, t.Max, t.Delta)
+	}
+	tw.Flush()
+}
+
+// writeFileResultsText выводит таблицу с результатами ревью по каждому файлу
+func writeFileResultsText(w io.Writer, results []client.FileResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tTYPE\tSCORE\tISSUES\tWARNINGS\tSTATUS")
+	for _, r := range results {
+		status := "ok"
+		score := fmt.Sprintf("%d", r.Score)
+		if r.Failed() {
+			status = "error"
+			score = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", r.Location(), fileKind(r), score, len(r.Issues), len(r.Warnings), status)
+	}
+	tw.Flush()
+
+	for _, r := range results {
+		if !r.Failed() && len(r.Issues) == 0 && len(r.Warnings) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s\n", r.Location())
+		if r.Failed() {
+			fmt.Fprintf(w, "  ❌ %v\n", r.Err)
+		}
+		for _, issue := range r.Issues {
+			fmt.Fprintf(w, "  ❗ %s\n", issue)
+		}
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(w, "  ⚠️ %s\n", warning)
+		}
+	}
+}
+
+// sortedSettings возвращает параметры, отсортированные по имени
+func sortedSettings(settings map[string]models.Setting) []models.Setting {
+	out := make([]models.Setting, 0, len(settings))
+	for _, st := range settings {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// settingValue возвращает нормализованное значение параметра, а если его нет — исходное
+func settingValue(st models.Setting) string {
+	if st.Display != "" {
+		return st.Display
+	}
+	return st.Setting
+}
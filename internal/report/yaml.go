@@ -0,0 +1,41 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeYAML выводит значение в YAML. Значение сначала кодируется в JSON,
+// чтобы имена полей совпадали с JSON-тегами моделей и порядок полей сохранялся.
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML report: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to encode YAML report: %w", err)
+	}
+	resetStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode YAML report: %w", err)
+	}
+	return enc.Close()
+}
+
+// resetStyle убирает flow-стиль JSON, чтобы YAML выводился в блочном виде
+func resetStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || node.Style == yaml.DoubleQuotedStyle {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
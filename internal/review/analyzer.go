@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// Analyzer analyzes server configuration, system metrics and migrations.
+// Client implements it through the remote review API.
+type Analyzer interface {
+	AnalyzeConfig(ctx context.Context, serverData models.ServerData, isSchedulerTask bool) (*models.Recommendation, error)
+	AnalyzeSystemMetrics(ctx context.Context, metrics collectors.SystemMetrics,
+		serverInfo models.ServerInfo, environment string, isSchedulerTask bool) (*models.Recommendation, error)
+	ReviewMigration(ctx context.Context, migration models.MigrationReviewRequest) (*models.MigrationReviewResponse, error)
+}
+
+var _ Analyzer = (*Client)(nil)
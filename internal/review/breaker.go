@@ -0,0 +1,118 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without a request while the circuit breaker is open
+var ErrCircuitOpen = errors.New("review API circuit breaker is open")
+
+// BreakerState is the persistent part of the circuit breaker state
+type BreakerState struct {
+	Failures  int       `json:"failures"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+// BreakerStore keeps the breaker state between runs, so scheduled runs do not retry
+// an API that failed a moment ago. Implementations report their own failures.
+type BreakerStore interface {
+	Load() BreakerState
+	Save(state BreakerState)
+}
+
+// CircuitBreaker stops requests to the review API after threshold consecutive failed requests.
+// After cooldown one probe request is let through: success closes the breaker, failure opens it again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	probing   bool
+	store     BreakerStore
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a breaker opening after threshold consecutive failures for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// WithStore loads the state from store and saves every change to it
+func (b *CircuitBreaker) WithStore(store BreakerStore) *CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.store = store
+	b.state = store.Load()
+	return b
+}
+
+// Allow returns ErrCircuitOpen while the breaker is open or a probe request is in flight
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state.OpenUntil.IsZero() {
+		return nil
+	}
+	now := b.now()
+	if now.Before(b.state.OpenUntil) {
+		return fmt.Errorf("%w, retry in %s", ErrCircuitOpen, b.state.OpenUntil.Sub(now).Round(time.Second))
+	}
+	if b.probing {
+		return fmt.Errorf("%w, waiting for a probe request", ErrCircuitOpen)
+	}
+	b.probing = true
+	return nil
+}
+
+// Success closes the breaker
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if b.state.Failures == 0 && b.state.OpenUntil.IsZero() {
+		return
+	}
+	b.state = BreakerState{}
+	b.save()
+}
+
+// Failure counts a failed request and opens the breaker after threshold failures or a failed probe
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state.Failures++
+	if b.probing || b.state.Failures >= b.threshold {
+		b.state.OpenUntil = b.now().Add(b.cooldown)
+	}
+	b.probing = false
+	b.save()
+}
+
+// Canceled releases a probe request that ended without an answer, so another one can be sent
+func (b *CircuitBreaker) Canceled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Open reports whether requests are currently rejected
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.state.OpenUntil.IsZero() && b.now().Before(b.state.OpenUntil)
+}
+
+func (b *CircuitBreaker) save() {
+	if b.store != nil {
+		b.store.Save(b.state)
+	}
+}
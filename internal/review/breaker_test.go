@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryBreakerStore keeps the breaker state in memory
+type memoryBreakerStore struct {
+	state BreakerState
+	saves int
+}
+
+func (s *memoryBreakerStore) Load() BreakerState { return s.state }
+
+func (s *memoryBreakerStore) Save(state BreakerState) {
+	s.state = state
+	s.saves++
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	// Закрыт: отказы ниже порога не мешают запросам
+	b.Failure()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("breaker opened below the threshold: %v", err)
+	}
+	b.Failure()
+	if !b.Open() {
+		t.Fatal("breaker should open after 2 failures")
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+
+	// Полуоткрыт: после cooldown проходит один пробный запрос
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe request rejected: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second request during a probe: got %v, want ErrCircuitOpen", err)
+	}
+
+	// Неудачная проба снова открывает выключатель
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after a failed probe: got %v, want ErrCircuitOpen", err)
+	}
+
+	// Отменённая проба не считается ни успехом, ни отказом
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe request rejected: %v", err)
+	}
+	b.Canceled()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe after a canceled one rejected: %v", err)
+	}
+
+	// Удачная проба закрывает выключатель и сбрасывает счётчик
+	b.Success()
+	if b.Open() {
+		t.Fatal("breaker should close after a successful probe")
+	}
+	b.Failure()
+	if err := b.Allow(); err != nil {
+		t.Errorf("failure counter was not reset: %v", err)
+	}
+}
+
+func TestCircuitBreakerStore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryBreakerStore{state: BreakerState{Failures: 3, OpenUntil: now.Add(time.Minute)}}
+	b := NewCircuitBreaker(3, time.Minute).WithStore(store)
+	b.now = func() time.Time { return now }
+
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("state from the store was not loaded: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	if store.state != (BreakerState{}) {
+		t.Errorf("saved state %+v, want a closed breaker", store.state)
+	}
+
+	// Успех закрытого выключателя не пишет состояние повторно
+	saves := store.saves
+	b.Success()
+	if store.saves != saves {
+		t.Error("unchanged state was saved")
+	}
+}
+
+func TestPostCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if code := int(status.Load()); code != http.StatusOK {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	c := NewClient(srv.URL).WithRetry(fastRetry(1)).WithCircuitBreaker(breaker)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.post(ctx, srv.URL, nil); !IsAPIError(err) || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: got %v, want a failed request", i, err)
+		}
+	}
+
+	// Открыт: запрос отклоняется без обращения к API
+	_, err := c.post(ctx, srv.URL, nil)
+	if !IsAPIError(err) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen as an API error", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+
+	// Полуоткрыт: проба к восстановившемуся API закрывает выключатель
+	now = now.Add(time.Minute)
+	status.Store(http.StatusOK)
+	if _, err := c.post(ctx, srv.URL, nil); err != nil {
+		t.Fatalf("probe request: %v", err)
+	}
+	if breaker.Open() {
+		t.Error("breaker should close after a successful probe")
+	}
+	if _, err := c.post(ctx, srv.URL, nil); err != nil {
+		t.Errorf("request after closing: %v", err)
+	}
+
+	// Ошибки клиента (4xx) не считаются отказами API
+	status.Store(http.StatusBadRequest)
+	for i := 0; i < 3; i++ {
+		c.post(ctx, srv.URL, nil)
+	}
+	if breaker.Open() {
+		t.Error("client errors should not open the breaker")
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/reviewcache"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+func TestCacheKeys(t *testing.T) {
+	c := NewClient("").WithCache(nil, "pg-version=16", "server=16.2")
+	query := models.QueryReviewRequest{SQL: "select *\n  from users;", Environment: "prod"}
+	key := c.queryCacheKey(query)
+
+	sameQuery := []models.QueryReviewRequest{
+		{SQL: "select * from users", Environment: "prod"},
+		{SQL: "  select *   from users ;\n", Environment: "prod"},
+		// План запроса меняется от запуска к запуску и в ключ не входит
+		{SQL: "select * from users;", Environment: "prod", QueryPlan: map[string]interface{}{"cost": 1}},
+	}
+	for _, q := range sameQuery {
+		if got := c.queryCacheKey(q); got != key {
+			t.Errorf("%q: key changed for the same query", q.SQL)
+		}
+	}
+
+	otherQuery := map[string]string{
+		"sql":         c.queryCacheKey(models.QueryReviewRequest{SQL: "select id from users", Environment: "prod"}),
+		"environment": c.queryCacheKey(models.QueryReviewRequest{SQL: query.SQL, Environment: "stage"}),
+		"tables":      c.queryCacheKey(models.QueryReviewRequest{SQL: query.SQL, Environment: "prod", Tables: []models.TableInfo{{Name: "users"}}}),
+		"server":      NewClient("").WithCache(nil, "pg-version=16", "server=16.3").queryCacheKey(query),
+		"no scope":    NewClient("").queryCacheKey(query),
+	}
+	for name, got := range otherQuery {
+		if got == key {
+			t.Errorf("key does not depend on %s", name)
+		}
+	}
+
+	migration := models.MigrationReviewRequest{SQL: "create index i on t (a);", RollbackSQL: "drop index i;", Transactional: true}
+	migrationKey := c.migrationCacheKey(migration)
+	if migrationKey == key {
+		t.Error("query and migration keys collide")
+	}
+	for name, m := range map[string]models.MigrationReviewRequest{
+		"rollback":      {SQL: migration.SQL, Transactional: true},
+		"transactional": {SQL: migration.SQL, RollbackSQL: migration.RollbackSQL},
+	} {
+		if c.migrationCacheKey(m) == migrationKey {
+			t.Errorf("migration key does not depend on %s", name)
+		}
+	}
+}
+
+func TestReviewFilesCache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req models.BatchReviewRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := models.BatchReviewResponse{}
+		for range req.Queries {
+			resp.Results = append(resp.Results, models.QueryReviewResponse{Score: 80, Issues: []string{"seq scan"}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	cache := reviewcache.New(t.TempDir())
+	files := []sqlfiles.SQLFile{{Title: "q", Path: "q.sql", Content: "select * from users;"}}
+	review := func(scope ...string) FileResult {
+		t.Helper()
+		results := NewClient(srv.URL).WithCache(cache, scope...).ReviewFiles(context.Background(), files, "test")
+		if len(results) != 1 || results[0].Err != nil {
+			t.Fatalf("got %+v", results)
+		}
+		return results[0]
+	}
+
+	first := review("server=16.2")
+	second := review("server=16.2")
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("got %d requests, want the second review from the cache", n)
+	}
+	if second.Score != first.Score || len(second.Issues) != 1 {
+		t.Errorf("cached result %+v differs from %+v", second, first)
+	}
+
+	// Новая версия или настройки сервера меняют scope, и старый ответ не используется
+	review("server=16.3")
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want a new request after the server changed", n)
+	}
+}
@@ -1,11 +1,10 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
 	"time"
 
@@ -17,6 +16,21 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	enrichers  []QueryEnricher
+	migrations MigrationReviewer
+	queries    QueryReviewer
+	localOnly  bool
+	statements bool
+	cache      ResponseCache
+	cacheScope []string
+
+	concurrency int
+	batchSize   int
+	limiter     *rateLimiter
+	progress    ProgressFunc
+
+	retry   RetryPolicy
+	breaker *CircuitBreaker
 }
 
 // NewClient creates a new analyzer client
@@ -26,6 +40,8 @@ func NewClient(baseURL string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		concurrency: 1,
+		retry:       DefaultRetryPolicy(),
 	}
 }
 
@@ -36,7 +52,7 @@ func (c *Client) AnalyzeConfig(ctx context.Context, serverData models.ServerData
 	}
 	url := fmt.Sprintf("%s/config/analyze", c.baseURL)
 
-	fmt.Printf("Using Review API URL: %s\n", url)
+	log.Printf("Using Review API URL: %s", url)
 
 	// Create request body
 	requestBody := serverData
@@ -47,34 +63,10 @@ func (c *Client) AnalyzeConfig(ctx context.Context, serverData models.ServerData
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	// Send request with retries
+	body, err := c.post(ctx, url, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	fmt.Printf("%s, %s", req.Body, req.URL.String())
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	fmt.Println(string(body))
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// Parse response
@@ -96,30 +88,10 @@ func (c *Client) ReviewSingleQuery(ctx context.Context, query models.QueryReview
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	// Send request with retries
+	body, err := c.post(ctx, url, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// Parse response
@@ -141,30 +113,10 @@ func (c *Client) ReviewBatchQueries(ctx context.Context, batch models.BatchRevie
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Send request with retries
+	body, err := c.post(ctx, url, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// Parse response
@@ -176,8 +128,17 @@ func (c *Client) ReviewBatchQueries(ctx context.Context, batch models.BatchRevie
 	return &response, nil
 }
 
-// ReviewMigration sends a migration SQL script for analysis
+// ReviewMigration sends a migration SQL script for analysis.
+// With a cache (WithCache) a previous response for the same script is returned without a request.
 func (c *Client) ReviewMigration(ctx context.Context, migration models.MigrationReviewRequest) (*models.MigrationReviewResponse, error) {
+	var key string
+	if c.cache != nil {
+		key = c.migrationCacheKey(migration)
+		var cached models.MigrationReviewResponse
+		if c.cache.Get(key, &cached) {
+			return &cached, nil
+		}
+	}
 	url := fmt.Sprintf("%s/review/", c.baseURL)
 
 	// Marshal to JSON
@@ -186,30 +147,10 @@ func (c *Client) ReviewMigration(ctx context.Context, migration models.Migration
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	// Send request with retries
+	body, err := c.post(ctx, url, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// Parse response
@@ -218,6 +159,10 @@ func (c *Client) ReviewMigration(ctx context.Context, migration models.Migration
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if c.cache != nil {
+		c.cache.Put(key, response)
+	}
+
 	return &response, nil
 }
 
@@ -268,30 +213,10 @@ func (c *Client) AnalyzeSystemMetrics(ctx context.Context, metrics collectors.Sy
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Send request with retries
+	body, err := c.post(ctx, url, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// Parse response
@@ -0,0 +1,446 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gitdiff"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// FileResult represents the review result mapped back to its SQL file
+type FileResult struct {
+	Path            string              `json:"path"`
+	Title           string              `json:"title"`
+	IsMigration     bool                `json:"is_migration"`
+	Migration       *sqlfiles.Migration `json:"migration,omitempty"`
+	StartLine       int                 `json:"start_line"`
+	EndLine         int                 `json:"end_line,omitempty"`
+	Statement       int                 `json:"statement,omitempty"`
+	Embedded        bool                `json:"embedded,omitempty"`
+	Score           int                 `json:"score"`
+	Recommendations []string            `json:"recommendations,omitempty"`
+	Issues          []string            `json:"issues,omitempty"`
+	Warnings        []string            `json:"warnings,omitempty"`
+	Tables          []models.TableInfo  `json:"tables,omitempty"`
+	QueryPlan       interface{}         `json:"query_plan,omitempty"`
+	SQL             string              `json:"-"`
+	Err             error               `json:"-"`
+}
+
+// QueryEnricher adds context such as table metadata to a query review request.
+// Enrichment is best effort: implementations report their own failures and leave the request as is.
+type QueryEnricher interface {
+	EnrichQuery(ctx context.Context, f sqlfiles.SQLFile, req *models.QueryReviewRequest)
+}
+
+// MigrationReviewer reviews migration scripts. Client implements it through the remote API.
+type MigrationReviewer interface {
+	ReviewMigration(ctx context.Context, migration models.MigrationReviewRequest) (*models.MigrationReviewResponse, error)
+}
+
+// WithMigrationReviewer sets the reviewer used for migration files in ReviewFiles,
+// for example local rules instead of the remote API
+func (c *Client) WithMigrationReviewer(reviewer MigrationReviewer) *Client {
+	c.migrations = reviewer
+	return c
+}
+
+// QueryReviewer reviews query files without the remote API, for example with static rules.
+// It checks the statements of f that start within firstLine..lastLine (the whole file when lastLine is 0).
+type QueryReviewer interface {
+	ReviewQuery(ctx context.Context, f sqlfiles.SQLFile, query models.QueryReviewRequest, firstLine, lastLine int) (*models.QueryReviewResponse, error)
+}
+
+// WithLocalQueryReview sets a reviewer whose results are merged into query file results.
+// With remote disabled query files are not sent to the review API at all;
+// otherwise the local result is used alone when the API is unavailable.
+func (c *Client) WithLocalQueryReview(reviewer QueryReviewer, remote bool) *Client {
+	c.queries = reviewer
+	c.localOnly = !remote
+	return c
+}
+
+// WithEnrichers sets enrichers applied to every query file request in ReviewFiles
+func (c *Client) WithEnrichers(enrichers ...QueryEnricher) *Client {
+	c.enrichers = enrichers
+	return c
+}
+
+// ResponseCache stores review API responses between runs. Lookups and writes are best effort:
+// implementations report their own failures and treat them as a miss.
+type ResponseCache interface {
+	Get(key string, v interface{}) bool
+	Put(key string, v interface{})
+}
+
+// WithCache makes the client reuse review API responses for unchanged SQL.
+// Keys cover the normalized SQL, environment, attached table metadata and scope,
+// which should identify the target server (version, settings) so a change invalidates old entries.
+// Local rule results are never cached.
+func (c *Client) WithCache(cache ResponseCache, scope ...string) *Client {
+	c.cache = cache
+	c.cacheScope = scope
+	return c
+}
+
+// WithStatementSplit makes ReviewFiles review every statement of a multi-statement
+// query file separately, so each statement gets its own score and line range.
+// Migrations are always reviewed as a whole.
+func (c *Client) WithStatementSplit(enabled bool) *Client {
+	c.statements = enabled
+	return c
+}
+
+// Failed reports whether the file could not be reviewed
+func (r FileResult) Failed() bool {
+	return r.Err != nil
+}
+
+// Location returns the file path, with the starting line for per-statement results
+// and SQL embedded in source code
+func (r FileResult) Location() string {
+	if r.Statement == 0 && !r.Embedded {
+		return r.Path
+	}
+	return fmt.Sprintf("%s:%d", r.Path, r.StartLine)
+}
+
+// ReviewFiles reviews normal SQL files in batch requests and migrations one by one.
+// Batches and migrations are reviewed by a pool of c.concurrency workers within the rate limit.
+// Every file gets a result; request failures, including cancellation of ctx, are stored in FileResult.Err.
+// Files that failed to expand (SQLFile.ExpandErr) are not reviewed; their results come last.
+func (c *Client) ReviewFiles(ctx context.Context, files []sqlfiles.SQLFile, environment string) []FileResult {
+	var migrations []sqlfiles.SQLFile
+	var normal []sqlfiles.SQLFile
+	var failed []FileResult
+	for _, f := range files {
+		if f.ExpandErr != nil {
+			// An unexpanded file would be reviewed with raw includes and placeholders
+			result := newFileResult(f)
+			result.Err = f.ExpandErr
+			failed = append(failed, result)
+			continue
+		}
+		if f.IsMigration {
+			migrations = append(migrations, f)
+		} else {
+			normal = append(normal, f)
+		}
+	}
+
+	units, sources, queries := c.queryRequests(ctx, normal, environment)
+	results := make([]FileResult, len(units)+len(migrations))
+
+	var jobs []reviewJob
+	size := c.batchSize
+	if size <= 0 || size > len(units) {
+		size = len(units)
+	}
+	for start := 0; start < len(units); start += size {
+		end := min(start+size, len(units))
+		jobs = append(jobs, reviewJob{
+			start: start,
+			size:  end - start,
+			run: func(ctx context.Context) []FileResult {
+				return c.reviewQueries(ctx, units[start:end], sources[start:end], queries[start:end], environment)
+			},
+			canceled: func(err error) []FileResult {
+				out := make([]FileResult, 0, end-start)
+				for i := start; i < end; i++ {
+					result := units[i]
+					result.Err = fmt.Errorf("review of %s canceled: %w", result.Title, err)
+					result.mapSource(sources[i])
+					out = append(out, result)
+				}
+				return out
+			},
+		})
+	}
+	for i, f := range migrations {
+		jobs = append(jobs, reviewJob{
+			start: len(units) + i,
+			size:  1,
+			run: func(ctx context.Context) []FileResult {
+				return []FileResult{c.reviewMigrationFile(ctx, f, environment)}
+			},
+			canceled: func(err error) []FileResult {
+				result := newFileResult(f)
+				result.mapSource(f)
+				result.Err = fmt.Errorf("review of migration %s canceled: %w", f.Title, err)
+				return []FileResult{result}
+			},
+		})
+	}
+
+	c.runJobs(ctx, jobs, results)
+	return append(results, failed...)
+}
+
+// queryRequests splits normal files into review units and builds their requests.
+// Enrichers run sequentially before any request is sent.
+func (c *Client) queryRequests(ctx context.Context, files []sqlfiles.SQLFile, environment string) ([]FileResult, []sqlfiles.SQLFile, []models.QueryReviewRequest) {
+	var units []FileResult
+	var sources []sqlfiles.SQLFile
+	for _, f := range files {
+		for _, unit := range c.queryUnits(f) {
+			units = append(units, unit)
+			sources = append(sources, f)
+		}
+	}
+
+	queries := make([]models.QueryReviewRequest, 0, len(units))
+	for i, unit := range units {
+		query := models.QueryReviewRequest{
+			SQL:         unit.SQL,
+			ThreadID:    unit.Title,
+			Environment: environment,
+		}
+		for _, e := range c.enrichers {
+			e.EnrichQuery(ctx, sources[i], &query)
+		}
+		queries = append(queries, query)
+	}
+	return units, sources, queries
+}
+
+// reviewQueries reviews query units in one batch request and merges local rule results
+func (c *Client) reviewQueries(ctx context.Context, units []FileResult, sources []sqlfiles.SQLFile, queries []models.QueryReviewRequest, environment string) []FileResult {
+	var remote []models.QueryReviewResponse
+	var err error
+	if !c.localOnly {
+		remote, err = c.reviewBatch(ctx, queries, environment)
+	}
+
+	results := make([]FileResult, 0, len(units))
+	for i, result := range units {
+		result.Tables = queries[i].Tables
+		result.QueryPlan = queries[i].QueryPlan
+
+		var local *models.QueryReviewResponse
+		var localErr error
+		if c.queries != nil {
+			first, last := 0, 0
+			if result.Statement > 0 {
+				first, last = result.StartLine, result.EndLine
+			}
+			local, localErr = c.queries.ReviewQuery(ctx, sources[i], queries[i], first, last)
+		}
+
+		switch {
+		case localErr != nil:
+			result.Err = fmt.Errorf("failed to review %s locally: %w", result.Title, localErr)
+		case local != nil && remote != nil:
+			result.applyQueryResponse(mergeQueryResponses(*local, remote[i]))
+		case local != nil:
+			result.applyQueryResponse(*local)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("review API is unavailable, local rules only: %v", err))
+			}
+		case err != nil:
+			result.Err = fmt.Errorf("failed to review batch queries: %w", err)
+		default:
+			result.applyQueryResponse(remote[i])
+		}
+		result.mapSource(sources[i])
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// reviewBatch sends queries to the review API in one request.
+// Queries with cached responses are not sent; fresh responses are cached.
+func (c *Client) reviewBatch(ctx context.Context, queries []models.QueryReviewRequest, environment string) ([]models.QueryReviewResponse, error) {
+	results := make([]models.QueryReviewResponse, len(queries))
+	keys := make([]string, len(queries))
+	var missing []int
+	var pending []models.QueryReviewRequest
+	for i, q := range queries {
+		if c.cache != nil {
+			keys[i] = c.queryCacheKey(q)
+			if c.cache.Get(keys[i], &results[i]) {
+				continue
+			}
+		}
+		missing = append(missing, i)
+		pending = append(pending, q)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	resp, err := c.ReviewBatchQueries(ctx, models.BatchReviewRequest{
+		Queries:     pending,
+		Environment: environment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(pending) {
+		return nil, fmt.Errorf("batch review returned %d results for %d queries", len(resp.Results), len(pending))
+	}
+
+	for j, i := range missing {
+		results[i] = resp.Results[j]
+		if c.cache != nil {
+			c.cache.Put(keys[i], results[i])
+		}
+	}
+	return results, nil
+}
+
+// queryCacheKey builds the cache key of a query review. The query plan is not part of the key:
+// it changes with statistics on every run, while table metadata reflects schema changes.
+func (c *Client) queryCacheKey(q models.QueryReviewRequest) string {
+	tables, _ := json.Marshal(q.Tables)
+	return cacheKey("query", c.cacheScope, q.Environment, normalizeSQL(q.SQL), string(tables))
+}
+
+// migrationCacheKey builds the cache key of a migration review
+func (c *Client) migrationCacheKey(m models.MigrationReviewRequest) string {
+	return cacheKey("migration", c.cacheScope, m.Environment, normalizeSQL(m.SQL), normalizeSQL(m.RollbackSQL),
+		strconv.FormatBool(m.Transactional))
+}
+
+// cacheKey hashes the key parts separated by a zero byte
+func cacheKey(kind string, scope []string, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(append(append([]string{kind}, scope...), parts...), "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeSQL collapses whitespace so reformatting does not invalidate cached reviews
+func normalizeSQL(sql string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(sql), " "), "; ")
+}
+
+// mergeQueryResponses combines local and remote results and keeps the lower score
+func mergeQueryResponses(local, remote models.QueryReviewResponse) models.QueryReviewResponse {
+	score := remote.Score
+	if local.Score < score {
+		score = local.Score
+	}
+
+	return models.QueryReviewResponse{
+		Score:           score,
+		Recommendations: append(append([]string{}, remote.Recommendations...), local.Recommendations...),
+		Issues:          append(append([]string{}, remote.Issues...), local.Issues...),
+	}
+}
+
+// queryUnits returns the review units of a query file: the whole file, or one unit
+// per SQL statement when statement splitting is enabled and the file has several.
+// For files changed relative to a git ref only statements touching changed lines are reviewed;
+// a changed file without known changed lines is reviewed as a whole instead of being dropped.
+// psql meta-commands are not sent for review.
+func (c *Client) queryUnits(f sqlfiles.SQLFile) []FileResult {
+	changed := len(f.ChangedLines) > 0
+	if !c.statements && !changed {
+		return []FileResult{newFileResult(f)}
+	}
+
+	var stmts []sqlfiles.Statement
+	for _, st := range sqlfiles.SplitStatements(f.Content) {
+		if st.Kind == sqlfiles.KindSQL {
+			stmts = append(stmts, st)
+		}
+	}
+	if len(stmts) <= 1 && !changed {
+		return []FileResult{newFileResult(f)}
+	}
+
+	units := make([]FileResult, 0, len(stmts))
+	for i, st := range stmts {
+		if changed && !gitdiff.Overlaps(f.ChangedLines, f.TopLine(st.Line), f.TopLine(st.EndLine)) {
+			continue
+		}
+		unit := newFileResult(f)
+		unit.SQL = st.SQL
+		unit.Statement = i + 1
+		unit.StartLine = st.Line
+		unit.EndLine = st.EndLine
+		units = append(units, unit)
+	}
+	return units
+}
+
+// reviewMigrationFile reviews a single migration file
+func (c *Client) reviewMigrationFile(ctx context.Context, f sqlfiles.SQLFile, environment string) FileResult {
+	result := newFileResult(f)
+	result.mapSource(f)
+
+	var reviewer MigrationReviewer = c
+	if c.migrations != nil {
+		reviewer = c.migrations
+	}
+
+	req := models.MigrationReviewRequest{
+		SQL:         f.Content,
+		Environment: environment,
+	}
+	// Откат отправляется вместе с миграцией, чтобы пара up/down ревьюилась целиком
+	if f.Migration != nil {
+		req.RollbackSQL = f.Migration.Down
+		req.Transactional = f.Migration.Transactional
+	}
+
+	resp, err := reviewer.ReviewMigration(ctx, req)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to review migration %s: %w", f.Title, err)
+		return result
+	}
+
+	result.applyMigrationResponse(*resp)
+	return result
+}
+
+func newFileResult(f sqlfiles.SQLFile) FileResult {
+	return FileResult{
+		Path:        f.Path,
+		Title:       f.Title,
+		IsMigration: f.IsMigration,
+		Migration:   f.Migration,
+		Embedded:    f.Embedded,
+		StartLine:   sqlfiles.FirstStatementLine(f.Content),
+		SQL:         f.Content,
+	}
+}
+
+// mapSource points line numbers of an expanded file (\i includes, psql variables) back to the source.
+// A statement that comes entirely from an included file is reported against that file;
+// otherwise lines of the reviewed file are used, with the \i line for included parts.
+// Review requests keep the expanded lines, so this runs after all reviewers.
+func (r *FileResult) mapSource(f sqlfiles.SQLFile) {
+	if f.Source == nil {
+		return
+	}
+	if r.Statement > 0 {
+		path, start := f.Origin(r.StartLine)
+		endPath, end := f.Origin(r.EndLine)
+		if path == endPath {
+			r.Path, r.StartLine, r.EndLine = path, start, end
+			return
+		}
+		r.EndLine = f.TopLine(r.EndLine)
+	}
+	r.StartLine = f.TopLine(r.StartLine)
+}
+
+func (r *FileResult) applyQueryResponse(resp models.QueryReviewResponse) {
+	r.Score = resp.Score
+	r.Recommendations = resp.Recommendations
+	r.Issues = resp.Issues
+}
+
+func (r *FileResult) applyMigrationResponse(resp models.MigrationReviewResponse) {
+	r.Score = resp.Score
+	r.Recommendations = resp.Recommendations
+	r.Issues = resp.Issues
+	r.Warnings = resp.Warnings
+}
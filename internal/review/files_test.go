@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gitdiff"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+func TestQueryUnitsChangedLines(t *testing.T) {
+	content := "select 1;\n\nselect 2;\n\nselect 3;"
+	tests := []struct {
+		name       string
+		statements bool
+		changed    []gitdiff.LineRange
+		want       []int // Номера операторов; 0 — файл целиком
+	}{
+		{name: "whole file", want: []int{0}},
+		{name: "split", statements: true, want: []int{1, 2, 3}},
+		{name: "changed statement", changed: []gitdiff.LineRange{{Start: 3, End: 3}}, want: []int{2}},
+		{name: "changed lines between statements", changed: []gitdiff.LineRange{{Start: 4, End: 4}}, want: nil},
+		{name: "changed file without hunks", changed: []gitdiff.LineRange{}, want: []int{0}},
+		{name: "changed file without hunks split", statements: true, changed: []gitdiff.LineRange{}, want: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("").WithStatementSplit(tt.statements)
+			units := c.queryUnits(sqlfiles.SQLFile{Title: "q", Path: "q.sql", Content: content, ChangedLines: tt.changed})
+
+			var got []int
+			for _, u := range units {
+				got = append(got, u.Statement)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got statements %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got statements %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReviewFilesSkipsUnexpandedFiles(t *testing.T) {
+	expandErr := errors.New("failed to expand q.sql: include cycle")
+	files := []sqlfiles.SQLFile{{Title: "q", Path: "q.sql", Content: "\\i q.sql", ExpandErr: expandErr}}
+
+	// Запрос к API не отправляется: адрес недоступен
+	results := NewClient("http://127.0.0.1:0").ReviewFiles(context.Background(), files, "test")
+	if len(results) != 1 || !errors.Is(results[0].Err, expandErr) || results[0].Title != "q" {
+		t.Errorf("got %+v, want the expansion error for q", results)
+	}
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProgressFunc is called after every reviewed item with the number of finished and total items.
+// Calls are serialized, done grows monotonically and reaches total at the end.
+type ProgressFunc func(done, total int)
+
+// WithConcurrency sets how many review requests ReviewFiles runs in parallel (1 by default)
+func (c *Client) WithConcurrency(n int) *Client {
+	if n < 1 {
+		n = 1
+	}
+	c.concurrency = n
+	return c
+}
+
+// WithBatchSize sets how many queries are sent in one batch request; 0 sends all queries in one batch
+func (c *Client) WithBatchSize(n int) *Client {
+	c.batchSize = n
+	return c
+}
+
+// WithRateLimit limits review API requests to perSecond with bursts of up to burst requests.
+// A non-positive rate disables the limit. Cached responses do not count against it.
+func (c *Client) WithRateLimit(perSecond float64, burst int) *Client {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return c
+	}
+	c.limiter = newRateLimiter(perSecond, burst)
+	return c
+}
+
+// WithProgress sets a callback reporting ReviewFiles progress
+func (c *Client) WithProgress(fn ProgressFunc) *Client {
+	c.progress = fn
+	return c
+}
+
+// wait blocks until the rate limit allows another API request or ctx is done
+func (c *Client) wait(ctx context.Context) error {
+	if c.limiter == nil {
+		return ctx.Err()
+	}
+	return c.limiter.Wait(ctx)
+}
+
+// reviewJob is a unit of work of ReviewFiles: a batch of query units or one migration.
+// run fills results[start:start+size].
+type reviewJob struct {
+	start int
+	size  int
+	run   func(ctx context.Context) []FileResult
+	// canceled returns the results reported when the job never started
+	canceled func(err error) []FileResult
+}
+
+// runJobs executes jobs with c.concurrency workers. Jobs that did not start before ctx
+// is done get their canceled results, so every item still has a result.
+func (c *Client) runJobs(ctx context.Context, jobs []reviewJob, results []FileResult) {
+	workers := c.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	var mu sync.Mutex
+	done := 0
+	finish := func(job reviewJob, out []FileResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		copy(results[job.start:job.start+job.size], out)
+		done += job.size
+		if c.progress != nil {
+			c.progress(done, len(results))
+		}
+	}
+
+	queue := make(chan reviewJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if err := ctx.Err(); err != nil {
+					finish(job, job.canceled(err))
+					continue
+				}
+				finish(job, job.run(ctx))
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// rateLimiter is a token bucket: tokens are added at rate per second up to burst
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait takes a token, sleeping until one is available or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait for the next token
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
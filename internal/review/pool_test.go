@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// migrationFiles returns n migrations whose SQL is their index
+func migrationFiles(n int) []sqlfiles.SQLFile {
+	files := make([]sqlfiles.SQLFile, n)
+	for i := range files {
+		files[i] = sqlfiles.SQLFile{
+			Title:       fmt.Sprintf("m%d", i),
+			Path:        fmt.Sprintf("m%d.sql", i),
+			Content:     strconv.Itoa(i),
+			IsMigration: true,
+		}
+	}
+	return files
+}
+
+// migrationScore answers a migration review with the migration index as the score
+func migrationScore(w http.ResponseWriter, r *http.Request) {
+	var req models.MigrationReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	score, _ := strconv.Atoi(req.SQL)
+	json.NewEncoder(w).Encode(models.MigrationReviewResponse{Score: score})
+}
+
+func TestRunJobsOrderAndProgress(t *testing.T) {
+	results := make([]FileResult, 10)
+	var jobs []reviewJob
+	for start := 0; start < len(results); start += 3 {
+		size := min(3, len(results)-start)
+		jobs = append(jobs, reviewJob{
+			start: start,
+			size:  size,
+			run: func(ctx context.Context) []FileResult {
+				// Первые задания завершаются последними
+				time.Sleep(time.Duration(len(results)-start) * time.Millisecond)
+				out := make([]FileResult, size)
+				for i := range out {
+					out[i].Score = start + i
+				}
+				return out
+			},
+			canceled: func(err error) []FileResult {
+				t.Errorf("job %d canceled: %v", start, err)
+				return make([]FileResult, size)
+			},
+		})
+	}
+
+	var progress []int
+	c := NewClient("").WithConcurrency(4).WithProgress(func(done, total int) {
+		if total != len(results) {
+			t.Errorf("progress total %d, want %d", total, len(results))
+		}
+		progress = append(progress, done)
+	})
+	c.runJobs(context.Background(), jobs, results)
+
+	for i, r := range results {
+		if r.Score != i {
+			t.Errorf("results[%d] holds the result of item %d", i, r.Score)
+		}
+	}
+	if len(progress) != len(jobs) || progress[len(progress)-1] != len(results) {
+		t.Fatalf("progress %v, want %d calls ending at %d", progress, len(jobs), len(results))
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] <= progress[i-1] {
+			t.Errorf("progress is not monotonic: %v", progress)
+		}
+	}
+}
+
+func TestReviewFilesKeepsOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.MigrationReviewRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		n, _ := strconv.Atoi(req.SQL)
+		// Ответы на первые миграции приходят позже остальных
+		time.Sleep(time.Duration(10-n) * time.Millisecond)
+		fmt.Fprintf(w, `{"overall_score": %d}`, n)
+	}))
+	defer srv.Close()
+
+	files := migrationFiles(10)
+	results := NewClient(srv.URL).WithConcurrency(4).ReviewFiles(context.Background(), files, "test")
+
+	if len(results) != len(files) {
+		t.Fatalf("got %d results, want %d", len(results), len(files))
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("results[%d]: %v", i, r.Err)
+		}
+		if r.Title != files[i].Title || r.Score != i {
+			t.Errorf("results[%d] = %s with score %d, want %s with score %d", i, r.Title, r.Score, files[i].Title, i)
+		}
+	}
+}
+
+func TestReviewFilesCanceledMidPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			migrationScore(w, r)
+			return
+		}
+		// Третий запрос прерывает ревью и висит, пока клиент не закроет соединение.
+		// Тело дочитывается, иначе сервер не заметит разрыв соединения.
+		io.Copy(io.Discard, r.Body)
+		cancel()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	files := migrationFiles(10)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	results := NewClient(srv.URL).WithConcurrency(2).WithCircuitBreaker(breaker).ReviewFiles(ctx, files, "test")
+
+	if len(results) != len(files) {
+		t.Fatalf("got %d results, want %d", len(results), len(files))
+	}
+	// Первые два запроса отвечены, но ответ второго мог не дойти до клиента до отмены;
+	// третий запрос освобождён завершением одного из них
+	reviewed := 0
+	for i, r := range results {
+		if r.Title != files[i].Title {
+			t.Errorf("results[%d] = %s, want %s", i, r.Title, files[i].Title)
+		}
+		if r.Err == nil {
+			if i >= 2 {
+				t.Errorf("results[%d] was reviewed after cancellation", i)
+			}
+			reviewed++
+			continue
+		}
+		if !errors.Is(r.Err, context.Canceled) || IsAPIError(r.Err) {
+			t.Errorf("results[%d]: got %v, want a cancellation that is not an API error", i, r.Err)
+		}
+	}
+	if reviewed == 0 {
+		t.Error("migrations answered before cancellation lost their results")
+	}
+	if got := requests.Load(); got > 4 {
+		t.Errorf("got %d requests, want no new requests after cancellation", got)
+	}
+	if breaker.Open() {
+		t.Error("cancellation should not open the circuit breaker")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("burst request %d waits %s", i, d)
+		}
+	}
+	if d := l.reserve(); d != 500*time.Millisecond {
+		t.Errorf("request over burst waits %s, want 500ms", d)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if d := l.reserve(); d != 250*time.Millisecond {
+		t.Errorf("after 250ms waits %s, want 250ms", d)
+	}
+	now = now.Add(250 * time.Millisecond)
+	if d := l.reserve(); d != 0 {
+		t.Errorf("after 500ms waits %s, want a token", d)
+	}
+
+	// Простой не накапливает больше burst токенов
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("burst request %d after idle waits %s", i, d)
+		}
+	}
+	if d := l.reserve(); d == 0 {
+		t.Error("tokens above burst were accumulated")
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := newRateLimiter(0.001, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// RequestIDHeader carries the id of a logical request. Retries of the same request reuse it,
+// so the review API can recognize a repeated POST and return the stored result.
+const RequestIDHeader = "X-Request-ID"
+
+// RetryPolicy controls retries of failed review API requests.
+// Network errors, 408, 429 and 5xx responses are retried with exponential backoff and jitter;
+// Retry-After of 429 and 503 responses is honored when it is longer than the backoff delay.
+type RetryPolicy struct {
+	MaxAttempts     int           // Attempts per request including the first one; 1 disables retries
+	InitialInterval time.Duration // Delay before the first retry
+	MaxInterval     time.Duration // Upper bound of the delay between attempts
+	MaxRetryAfter   time.Duration // Longest Retry-After the client waits for; longer ones fail the request
+}
+
+// DefaultRetryPolicy returns the policy used by NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		MaxRetryAfter:   time.Minute,
+	}
+}
+
+// WithRetry sets the retry policy of review API requests
+func (c *Client) WithRetry(policy RetryPolicy) *Client {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	c.retry = policy
+	return c
+}
+
+// WithCircuitBreaker makes the client fail fast while the breaker is open.
+// One breaker can be shared by several clients talking to the same API.
+func (c *Client) WithCircuitBreaker(breaker *CircuitBreaker) *Client {
+	c.breaker = breaker
+	return c
+}
+
+// APIError marks failures of the review API itself: transport errors, error responses
+// and requests rejected by the open circuit breaker. Local and canceled reviews are not APIErrors.
+type APIError struct {
+	Err error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsAPIError reports whether err, or an error it wraps, is an APIError
+func IsAPIError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr)
+}
+
+// StatusError is returned when the review API responds with a status other than 200
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether the same request may succeed later
+func (e *StatusError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return e.StatusCode >= 500
+}
+
+// post sends a JSON body to url and returns the body of a 200 response.
+// It waits for the rate limit before every attempt, retries transient failures
+// according to c.retry and reports the outcome to the circuit breaker.
+func (c *Client) post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	if c.breaker != nil {
+		if err := c.breaker.Allow(); err != nil {
+			return nil, &APIError{Err: err}
+		}
+	}
+
+	requestID, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = c.retry.InitialInterval
+	b.MaxInterval = c.retry.MaxInterval
+	b.MaxElapsedTime = 0
+	b.Reset()
+
+	for attempt := 1; ; attempt++ {
+		body, err := c.send(ctx, url, payload, requestID)
+		if err == nil {
+			c.recordSuccess()
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			// Cancellation says nothing about the API health
+			c.recordCanceled()
+			return nil, ctx.Err()
+		}
+
+		var status *StatusError
+		if errors.As(err, &status) && !status.retryable() {
+			// The API is up and rejected the request itself
+			c.recordSuccess()
+			return nil, &APIError{Err: err}
+		}
+		if attempt >= c.retry.MaxAttempts {
+			c.recordFailure()
+			if attempt > 1 {
+				err = fmt.Errorf("request %s failed after %d attempts: %w", requestID, attempt, err)
+			}
+			return nil, &APIError{Err: err}
+		}
+
+		delay := b.NextBackOff()
+		if status != nil && status.RetryAfter > delay {
+			if status.RetryAfter > c.retry.MaxRetryAfter {
+				c.recordFailure()
+				return nil, &APIError{Err: fmt.Errorf("review API asked to retry after %s: %w", status.RetryAfter, err)}
+			}
+			delay = status.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.recordCanceled()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs one attempt of a request
+func (c *Client) send(ctx context.Context, url string, payload []byte, requestID string) ([]byte, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(RequestIDHeader, requestID)
+
+	// Send request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		err := &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			err.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (c *Client) recordSuccess() {
+	if c.breaker != nil {
+		c.breaker.Success()
+	}
+}
+
+func (c *Client) recordFailure() {
+	if c.breaker != nil {
+		c.breaker.Failure()
+	}
+}
+
+func (c *Client) recordCanceled() {
+	if c.breaker != nil {
+		c.breaker.Canceled()
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// It returns 0 when the header is missing or invalid.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// newRequestID returns a random id for RequestIDHeader
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry retries without noticeable delays
+func fastRetry(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     attempts,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxRetryAfter:   time.Minute,
+	}
+}
+
+func TestPostRetriesTooManyRequestsAfterRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	var requestIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		first := len(requestIDs) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL).WithRetry(fastRetry(3))
+	start := time.Now()
+	body, err := c.post(context.Background(), srv.URL, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("got body %q", body)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the Retry-After of 1s", elapsed)
+	}
+	if len(requestIDs) != 2 || requestIDs[0] == "" || requestIDs[0] != requestIDs[1] {
+		t.Errorf("request ids %q, want the same id on both attempts", requestIDs)
+	}
+}
+
+func TestPostRetryAfterLongerThanLimit(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL).WithRetry(fastRetry(3)).post(context.Background(), srv.URL, nil)
+	if !IsAPIError(err) {
+		t.Fatalf("got %v, want an API error", err)
+	}
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests || status.RetryAfter != time.Hour {
+		t.Errorf("got %v, want 429 with Retry-After of 1h", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestPostRetryableStatuses(t *testing.T) {
+	tests := []struct {
+		status   int
+		requests int32
+	}{
+		{http.StatusInternalServerError, 3},
+		{http.StatusBadGateway, 3},
+		{http.StatusRequestTimeout, 3},
+		{http.StatusBadRequest, 1},
+		{http.StatusUnprocessableEntity, 1},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				http.Error(w, "failed", tt.status)
+			}))
+			defer srv.Close()
+
+			_, err := NewClient(srv.URL).WithRetry(fastRetry(3)).post(context.Background(), srv.URL, nil)
+			var status *StatusError
+			if !IsAPIError(err) || !errors.As(err, &status) || status.StatusCode != tt.status {
+				t.Errorf("got %v, want an API error with status %d", err, tt.status)
+			}
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("got %d requests, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestPostCanceledDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(srv.URL).WithRetry(fastRetry(3)).post(ctx, srv.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || IsAPIError(err) {
+		t.Errorf("got %v, want the context error", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
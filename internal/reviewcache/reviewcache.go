@@ -0,0 +1,193 @@
+package reviewcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// Cache локальный кеш ответов review API: один JSON-файл на ключ в каталоге dir.
+// Ошибки чтения и записи пишутся в лог и считаются промахом: ревью выполняется и без кеша.
+type Cache struct {
+	dir   string
+	ttl   time.Duration
+	now   func() time.Time
+	hits  int
+	stale int
+}
+
+// entry запись кеша на диске
+type entry struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Response  json.RawMessage `json:"response"`
+}
+
+// New создает кеш в каталоге dir. Каталог создается при первой записи.
+func New(dir string) *Cache {
+	return &Cache{dir: dir, now: time.Now}
+}
+
+// WithTTL задает срок жизни записей; 0 — записи не устаревают
+func (c *Cache) WithTTL(ttl time.Duration) *Cache {
+	c.ttl = ttl
+	return c
+}
+
+// DefaultDir возвращает каталог кеша по умолчанию: <user cache dir>/pgmon/reviews
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "pgmon", "reviews"), nil
+}
+
+// Get читает ответ по ключу в v. Устаревшая запись удаляется и считается промахом.
+func (c *Cache) Get(key string, v interface{}) bool {
+	data, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return false
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to read review cache: %v", err)
+		return false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		log.Printf("⚠️ Corrupted review cache entry %s: %v", key, err)
+		_ = os.Remove(c.path(key))
+		return false
+	}
+	if c.ttl > 0 && c.now().Sub(e.CreatedAt) > c.ttl {
+		c.stale++
+		_ = os.Remove(c.path(key))
+		return false
+	}
+	if err := json.Unmarshal(e.Response, v); err != nil {
+		log.Printf("⚠️ Corrupted review cache entry %s: %v", key, err)
+		return false
+	}
+
+	c.hits++
+	return true
+}
+
+// Put сохраняет ответ по ключу. Запись выполняется через временный файл,
+// чтобы параллельные запуски не прочитали недописанную запись.
+func (c *Cache) Put(key string, v interface{}) {
+	if err := c.put(key, v); err != nil {
+		log.Printf("⚠️ Failed to write review cache: %v", err)
+	}
+}
+
+func (c *Cache) put(key string, v interface{}) error {
+	response, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	data, err := json.Marshal(entry{CreatedAt: c.now().UTC(), Response: response})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Stats возвращает число попаданий и устаревших записей за время жизни кеша
+func (c *Cache) Stats() (hits, stale int) {
+	return c.hits, c.stale
+}
+
+// Prune удаляет устаревшие записи, в том числе оставшиеся от прежних версий и настроек сервера,
+// которые больше не будут запрошены. Возвращает число удалённых записей.
+func (c *Cache) Prune() (int, error) {
+	if c.ttl <= 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		// Время изменения файла совпадает со временем записи: записи не перезаписываются на месте
+		if c.now().Sub(info.ModTime()) > c.ttl {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune review cache: %w", err)
+	}
+	return removed, nil
+}
+
+// Scope возвращает часть ключа, описывающую целевой сервер: версию из --pg-version, версию сервера
+// и отпечаток его настроек. Без версии сервера (нет подключения к базе) в scope входит только pgVersion.
+// Параметры сеанса и клиента (application_name и т.п.) отличаются между подключениями и не учитываются.
+func Scope(pgVersion int, serverVersion string, settings map[string]models.Setting) []string {
+	scope := []string{"pg-version=" + strconv.Itoa(pgVersion)}
+	if serverVersion == "" {
+		return scope
+	}
+
+	names := make([]string, 0, len(settings))
+	for name, s := range settings {
+		if s.Source != "client" && s.Source != "session" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, settings[name].Setting)
+	}
+	return append(scope, "server="+serverVersion, "settings="+hex.EncodeToString(h.Sum(nil)))
+}
+
+// path раскладывает записи по подкаталогам по первым символам ключа
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
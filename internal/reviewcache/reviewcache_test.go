@@ -0,0 +1,158 @@
+package reviewcache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+const testKey = "ab12cd34"
+
+type response struct {
+	Score  int      `json:"score"`
+	Issues []string `json:"issues"`
+}
+
+func TestGetPut(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "reviews"))
+
+	var got response
+	if c.Get(testKey, &got) {
+		t.Fatal("hit in an empty cache")
+	}
+
+	want := response{Score: 70, Issues: []string{"seq scan"}}
+	c.Put(testKey, want)
+	if !c.Get(testKey, &got) || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(c.dir, "ab", testKey+".json")); err != nil {
+		t.Errorf("entry is not stored under the key prefix: %v", err)
+	}
+	if hits, stale := c.Stats(); hits != 1 || stale != 0 {
+		t.Errorf("stats %d hits, %d stale, want 1, 0", hits, stale)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(t.TempDir()).WithTTL(time.Hour)
+	c.now = func() time.Time { return now }
+	c.Put(testKey, response{Score: 90})
+
+	var got response
+	now = now.Add(time.Hour)
+	if !c.Get(testKey, &got) {
+		t.Fatal("entry expired at the TTL boundary")
+	}
+
+	now = now.Add(time.Second)
+	if c.Get(testKey, &got) {
+		t.Fatal("expired entry was returned")
+	}
+	if _, err := os.Stat(c.path(testKey)); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed: %v", err)
+	}
+	if _, stale := c.Stats(); stale != 1 {
+		t.Errorf("got %d stale entries, want 1", stale)
+	}
+
+	// Без TTL записи не устаревают
+	c = New(c.dir)
+	c.now = func() time.Time { return now }
+	c.Put(testKey, response{Score: 90})
+	c.now = func() time.Time { return now.Add(24 * 365 * time.Hour) }
+	if !c.Get(testKey, &got) {
+		t.Error("entry expired without a TTL")
+	}
+}
+
+func TestCorruptedEntry(t *testing.T) {
+	c := New(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(c.path(testKey)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.path(testKey), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got response
+	if c.Get(testKey, &got) {
+		t.Fatal("corrupted entry was returned")
+	}
+	if _, err := os.Stat(c.path(testKey)); !os.IsNotExist(err) {
+		t.Errorf("corrupted entry was not removed: %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	c := New(t.TempDir()).WithTTL(time.Hour)
+	c.Put("aa0001", response{})
+	c.Put("bb0002", response{})
+	if err := os.Chtimes(c.path("aa0001"), now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d entries, want 1", removed)
+	}
+	var got response
+	if c.Get("aa0001", &got) || !c.Get("bb0002", &got) {
+		t.Error("prune removed the wrong entries")
+	}
+
+	// Отсутствующий каталог — не ошибка
+	if removed, err := New(filepath.Join(t.TempDir(), "missing")).WithTTL(time.Hour).Prune(); err != nil || removed != 0 {
+		t.Errorf("prune of a missing directory: %d, %v", removed, err)
+	}
+}
+
+func TestScope(t *testing.T) {
+	settings := map[string]models.Setting{
+		"work_mem":         {Setting: "4096", Source: "configuration file"},
+		"shared_buffers":   {Setting: "16384", Source: "configuration file"},
+		"application_name": {Setting: "psql", Source: "client"},
+	}
+	base := Scope(16, "16.2", settings)
+
+	withSetting := func(name string, s models.Setting) map[string]models.Setting {
+		out := make(map[string]models.Setting, len(settings)+1)
+		for k, v := range settings {
+			out[k] = v
+		}
+		out[name] = s
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		scope []string
+		same  bool
+	}{
+		{"same server", Scope(16, "16.2", withSetting("work_mem", settings["work_mem"])), true},
+		{"client setting changed", Scope(16, "16.2", withSetting("application_name", models.Setting{Setting: "pgmon", Source: "client"})), true},
+		{"session setting added", Scope(16, "16.2", withSetting("search_path", models.Setting{Setting: "app", Source: "session"})), true},
+		{"server version changed", Scope(16, "16.3", settings), false},
+		{"pg version changed", Scope(15, "16.2", settings), false},
+		{"setting changed", Scope(16, "16.2", withSetting("work_mem", models.Setting{Setting: "8192", Source: "configuration file"})), false},
+		{"setting added", Scope(16, "16.2", withSetting("jit", models.Setting{Setting: "off", Source: "default"})), false},
+	}
+	for _, tt := range tests {
+		if got := reflect.DeepEqual(tt.scope, base); got != tt.same {
+			t.Errorf("%s: same scope = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+
+	if got := Scope(16, "", settings); !reflect.DeepEqual(got, []string{"pg-version=16"}) {
+		t.Errorf("scope without a server = %q", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/dreadew/go-common/pkg/clients/db/impl"
 	"github.com/hashicorp/vault/api"
 	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
 	"github.com/ratmirtech/postgresql-query-monitor/pkg/vault"
 )
 
@@ -16,6 +17,7 @@ import (
 type ServerInfoCollector struct {
 	vaultClient *api.Client
 	vaultPath   string
+	snapshot    *SnapshotFilter
 }
 
 // NewServerInfoCollector создает новый коллектор
@@ -29,11 +31,12 @@ func NewServerInfoCollector(vaultClient *api.Client, vaultPath string) *ServerIn
 func (c *ServerInfoCollector) CollectServerData(ctx context.Context) (models.ServerData, error) {
 	var data models.ServerData
 
-	config, err := c.CollectConfig(ctx)
+	settings, err := c.CollectSettings(ctx)
 	if err != nil {
 		return data, fmt.Errorf("failed to collect config: %w", err)
 	}
-	data.Config = config
+	data.Config = ConfigFromSettings(settings)
+	data.Settings = settings
 
 	serverInfo, err := c.CollectServerInfo(ctx)
 	if err != nil {
@@ -41,6 +44,12 @@ func (c *ServerInfoCollector) CollectServerData(ctx context.Context) (models.Ser
 	}
 	data.ServerInfo = serverInfo
 
+	extensions, err := c.CollectExtensions(ctx)
+	if err != nil {
+		return data, fmt.Errorf("failed to collect extensions: %w", err)
+	}
+	data.Extensions = extensions
+
 	data.Environment = fmt.Sprintf("%s@%s/%s", data.ServerInfo.Version, data.ServerInfo.Host, data.ServerInfo.Database)
 
 	return data, nil
@@ -59,6 +68,17 @@ func (c *ServerInfoCollector) CollectServerInfo(ctx context.Context) (models.Ser
 	return GetServerInfo(ctx, client)
 }
 
+// CollectExtensions собирает список установленных расширений
+func (c *ServerInfoCollector) CollectExtensions(ctx context.Context) ([]models.Extension, error) {
+	clientWrap, err := CreateDbWrap(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	defer clientWrap.Close()
+
+	return GetExtensions(ctx, clientWrap.DB())
+}
+
 func CreateDbWrap(ctx context.Context, c *ServerInfoCollector) (db.DatabaseClient, error) {
 	cfg, err := vault.GetConnectionConfig(ctx, c.vaultClient, c.vaultPath)
 	if err != nil {
@@ -105,42 +125,57 @@ func GetServerInfo(ctx context.Context, client db.DB) (models.ServerInfo, error)
 }
 
 func (c *ServerInfoCollector) CollectConfig(ctx context.Context) (models.Config, error) {
-	clientWrap, err := CreateDbWrap(ctx, c)
+	settings, err := c.CollectSettings(ctx)
 	if err != nil {
 		return models.Config{}, err
 	}
+
+	return ConfigFromSettings(settings), nil
+}
+
+// CollectSettings собирает параметры конфигурации вместе с единицами измерения.
+// В режиме снимка (WithSnapshot) собираются все параметры pg_settings, прошедшие фильтр,
+// плюс параметры models.Config для обратной совместимости.
+func (c *ServerInfoCollector) CollectSettings(ctx context.Context) (map[string]models.Setting, error) {
+	clientWrap, err := CreateDbWrap(ctx, c)
+	if err != nil {
+		return nil, err
+	}
 	defer clientWrap.Close()
 
-	return GetConfig(ctx, clientWrap.DB())
+	if c.snapshot != nil {
+		return GetSnapshot(ctx, clientWrap.DB(), *c.snapshot)
+	}
+	return GetSettings(ctx, clientWrap.DB(), configParameters)
 }
 
-func GetConfig(ctx context.Context, client db.DB) (models.Config, error) {
-	var config models.Config
+// configParameters параметры, входящие в models.Config
+var configParameters = []string{
+	"shared_buffers",
+	"effective_cache_size",
+	"maintenance_work_mem",
+	"checkpoint_completion_target",
+	"wal_buffers",
+	"default_statistics_target",
+	"random_page_cost",
+	"effective_io_concurrency",
+	"work_mem",
+	"min_wal_size",
+	"max_wal_size",
+}
 
-	query := `
-		SELECT 
-			name, setting 
-		FROM pg_settings 
-		WHERE name IN (
-			'shared_buffers',
-			'effective_cache_size', 
-			'maintenance_work_mem',
-			'checkpoint_completion_target',
-			'wal_buffers',
-			'default_statistics_target',
-			'random_page_cost',
-			'effective_io_concurrency',
-			'work_mem',
-			'min_wal_size',
-			'max_wal_size'
-		)
-	`
-
-	rows, err := client.QueryContext(ctx, db.Query{Raw: query})
+func GetConfig(ctx context.Context, client db.DB) (models.Config, error) {
+	settings, err := GetSettings(ctx, client, configParameters)
 	if err != nil {
-		return config, fmt.Errorf("failed to query config parameters: %w", err)
+		return models.Config{}, err
 	}
-	defer rows.Close()
+
+	return ConfigFromSettings(settings), nil
+}
+
+// ConfigFromSettings заполняет models.Config сырыми значениями из pg_settings
+func ConfigFromSettings(settings map[string]models.Setting) models.Config {
+	var config models.Config
 
 	// Маппинг полей для установки значений
 	fieldMap := map[string]*string{
@@ -157,20 +192,116 @@ func GetConfig(ctx context.Context, client db.DB) (models.Config, error) {
 		"max_wal_size":                 &config.MaxWalSize,
 	}
 
+	for name, field := range fieldMap {
+		if setting, exists := settings[name]; exists {
+			*field = setting.Setting
+		}
+	}
+
+	return config
+}
+
+// GetSettings читает указанные параметры из pg_settings вместе с метаданными
+// и нормализует значения в байты или миллисекунды
+func GetSettings(ctx context.Context, client db.DB, names []string) (map[string]models.Setting, error) {
+	return querySettings(ctx, client, db.Query{
+		Name: "get_settings",
+		Raw:  settingsQuery + " WHERE name = ANY($1)",
+	}, names)
+}
+
+// settingsQuery выборка параметров pg_settings с метаданными
+const settingsQuery = `
+		SELECT
+			name,
+			setting,
+			COALESCE(unit, ''),
+			vartype,
+			COALESCE(category, ''),
+			COALESCE(min_val, ''),
+			COALESCE(max_val, ''),
+			COALESCE(boot_val, ''),
+			COALESCE(reset_val, ''),
+			source,
+			pending_restart
+		FROM pg_settings`
+
+// querySettings выполняет запрос на основе settingsQuery и нормализует значения
+func querySettings(ctx context.Context, client db.DB, q db.Query, args ...interface{}) (map[string]models.Setting, error) {
+	rows, err := client.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config parameters: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]models.Setting)
 	for rows.Next() {
-		var name, setting string
-		if err := rows.Scan(&name, &setting); err != nil {
-			return config, fmt.Errorf("failed to scan config parameter: %w", err)
+		var s models.Setting
+		if err := rows.Scan(&s.Name, &s.Setting, &s.Unit, &s.VarType, &s.Category, &s.MinVal, &s.MaxVal,
+			&s.BootVal, &s.ResetVal, &s.Source, &s.PendingRestart); err != nil {
+			return nil, fmt.Errorf("failed to scan config parameter: %w", err)
 		}
 
-		if field, exists := fieldMap[name]; exists {
-			*field = setting
+		NormalizeSetting(&s)
+		settings[s.Name] = s
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating config rows: %w", err)
+	}
+
+	return settings, nil
+}
+
+// NormalizeSetting переводит числовое значение параметра в байты или миллисекунды по колонке unit.
+// Отрицательные значения (например, -1 для автоматического wal_buffers) не нормализуются.
+func NormalizeSetting(s *models.Setting) {
+	s.Bytes, s.Milliseconds, s.Display = nil, nil, ""
+
+	if s.VarType != "integer" && s.VarType != "real" {
+		return
+	}
+	value, ok := units.ParseFloat(s.Setting)
+	if !ok || value < 0 {
+		return
+	}
+
+	kind, multiplier := units.ParseUnit(s.Unit)
+	switch kind {
+	case units.KindBytes:
+		bytes := int64(value * multiplier)
+		s.Bytes = &bytes
+		s.Display = units.FormatBytes(bytes)
+	case units.KindTime:
+		ms := value * multiplier
+		s.Milliseconds = &ms
+		s.Display = units.FormatDuration(ms)
+	}
+}
+
+// GetExtensions читает установленные расширения из pg_extension
+func GetExtensions(ctx context.Context, client db.DB) ([]models.Extension, error) {
+	rows, err := client.QueryContext(ctx, db.Query{
+		Name: "get_extensions",
+		Raw:  "SELECT extname, extversion FROM pg_extension ORDER BY extname",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var extensions []models.Extension
+	for rows.Next() {
+		var ext models.Extension
+		if err := rows.Scan(&ext.Name, &ext.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
 		}
+		extensions = append(extensions, ext)
 	}
 
 	if err := rows.Err(); err != nil {
-		return config, fmt.Errorf("error iterating config rows: %w", err)
+		return nil, fmt.Errorf("error iterating extension rows: %w", err)
 	}
 
-	return config, nil
+	return extensions, nil
 }
@@ -0,0 +1,57 @@
+package serverinfo
+
+import (
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+func TestNormalizeSetting(t *testing.T) {
+	tests := []struct {
+		name         string
+		setting      models.Setting
+		bytes        int64 // -1 — не нормализуется в байты
+		milliseconds float64
+		display      string
+	}{
+		{"8kB pages", models.Setting{Setting: "16384", Unit: "8kB", VarType: "integer"}, 128 << 20, -1, "128MB"},
+		{"kB", models.Setting{Setting: "4096", Unit: "kB", VarType: "integer"}, 4 << 20, -1, "4MB"},
+		{"MB", models.Setting{Setting: "1024", Unit: "MB", VarType: "integer"}, 1 << 30, -1, "1GB"},
+		{"16MB segments", models.Setting{Setting: "5", Unit: "16MB", VarType: "integer"}, 80 << 20, -1, "80MB"},
+		{"ms", models.Setting{Setting: "60000", Unit: "ms", VarType: "integer"}, -1, 60000, "1min"},
+		{"s", models.Setting{Setting: "300", Unit: "s", VarType: "integer"}, -1, 300000, "5min"},
+		{"min", models.Setting{Setting: "90", Unit: "min", VarType: "integer"}, -1, 5400000, "90min"},
+		{"real ms", models.Setting{Setting: "0.5", Unit: "ms", VarType: "real"}, -1, 0.5, "0.5ms"},
+		{"real without unit", models.Setting{Setting: "1.1", VarType: "real"}, -1, -1, ""},
+		{"auto -1", models.Setting{Setting: "-1", Unit: "8kB", VarType: "integer"}, -1, -1, ""},
+		{"disabled -1 ms", models.Setting{Setting: "-1", Unit: "ms", VarType: "integer"}, -1, -1, ""},
+		{"zero", models.Setting{Setting: "0", Unit: "ms", VarType: "integer"}, -1, 0, "0ms"},
+		{"string", models.Setting{Setting: "on", VarType: "bool"}, -1, -1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setting
+			// Старые значения сбрасываются при повторной нормализации
+			stale := int64(1)
+			s.Bytes, s.Display = &stale, "stale"
+			NormalizeSetting(&s)
+
+			switch {
+			case tt.bytes < 0 && s.Bytes != nil:
+				t.Errorf("bytes = %d, want none", *s.Bytes)
+			case tt.bytes >= 0 && (s.Bytes == nil || *s.Bytes != tt.bytes):
+				t.Errorf("bytes = %v, want %d", s.Bytes, tt.bytes)
+			}
+			switch {
+			case tt.milliseconds < 0 && s.Milliseconds != nil:
+				t.Errorf("milliseconds = %v, want none", *s.Milliseconds)
+			case tt.milliseconds >= 0 && (s.Milliseconds == nil || *s.Milliseconds != tt.milliseconds):
+				t.Errorf("milliseconds = %v, want %v", s.Milliseconds, tt.milliseconds)
+			}
+			if s.Display != tt.display {
+				t.Errorf("display = %q, want %q", s.Display, tt.display)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package serverinfo
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+)
+
+// SnapshotFilter фильтр полного снимка pg_settings.
+// Пустой фильтр пропускает все параметры; Deny имеет приоритет над Allow.
+type SnapshotFilter struct {
+	Categories []string // Префиксы категорий без учёта регистра, например "autovacuum", "resource usage"
+	Allow      []string // Шаблоны имён (path.Match), например "autovacuum_*"
+	Deny       []string // Шаблоны имён, которые исключаются всегда
+}
+
+// WithSnapshot включает режим полного снимка pg_settings с указанным фильтром
+func (c *ServerInfoCollector) WithSnapshot(filter SnapshotFilter) *ServerInfoCollector {
+	c.snapshot = &filter
+	return c
+}
+
+// GetSnapshot читает все параметры pg_settings и оставляет прошедшие фильтр.
+// Параметры models.Config сохраняются всегда, чтобы заполнить устаревшую структуру.
+func GetSnapshot(ctx context.Context, client db.DB, filter SnapshotFilter) (map[string]models.Setting, error) {
+	all, err := querySettings(ctx, client, db.Query{
+		Name: "get_settings_snapshot",
+		Raw:  settingsQuery,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := make(map[string]bool, len(configParameters))
+	for _, name := range configParameters {
+		legacy[name] = true
+	}
+
+	settings := make(map[string]models.Setting, len(all))
+	for name, s := range all {
+		if legacy[name] || filter.Match(s) {
+			settings[name] = s
+		}
+	}
+
+	return settings, nil
+}
+
+// Match проверяет, проходит ли параметр фильтр
+func (f SnapshotFilter) Match(s models.Setting) bool {
+	name := strings.ToLower(s.Name)
+
+	if matchAny(f.Deny, name) {
+		return false
+	}
+	if len(f.Allow) > 0 && !matchAny(f.Allow, name) {
+		return false
+	}
+	if len(f.Categories) > 0 && !matchCategory(f.Categories, s.Category) {
+		return false
+	}
+	return true
+}
+
+// matchAny проверяет имя по списку шаблонов
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if ok, err := path.Match(p, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCategory проверяет категорию параметра по списку префиксов
+func matchCategory(categories []string, category string) bool {
+	category = strings.ToLower(category)
+	for _, c := range categories {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != "" && strings.HasPrefix(category, c) {
+			return true
+		}
+	}
+	return false
+}
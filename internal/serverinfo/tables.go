@@ -0,0 +1,119 @@
+package serverinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// tableInfoQuery метаданные таблицы: оценка строк из pg_class, определения индексов
+// из pg_index, статистика сканирований из pg_stat_user_tables и типы колонок
+// из information_schema.columns. Имя разрешается через search_path, как в запросе.
+const tableInfoQuery = `
+		SELECT
+			n.nspname,
+			c.relname,
+			GREATEST(c.reltuples, 0)::bigint,
+			pg_total_relation_size(c.oid),
+			COALESCE(s.seq_scan, 0),
+			COALESCE(s.idx_scan, 0),
+			COALESCE((
+				SELECT array_agg(pg_get_indexdef(i.indexrelid) ORDER BY i.indexrelid)
+				FROM pg_index i
+				WHERE i.indrelid = c.oid
+			), '{}'),
+			COALESCE((
+				SELECT json_agg(json_build_object(
+					'name', col.column_name,
+					'type', CASE WHEN col.data_type = 'USER-DEFINED' THEN col.udt_name ELSE col.data_type END,
+					'nullable', col.is_nullable = 'YES'
+				) ORDER BY col.ordinal_position)
+				FROM information_schema.columns col
+				WHERE col.table_schema = n.nspname AND col.table_name = c.relname
+			), '[]')::text
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.oid = to_regclass(CASE WHEN $1 = '' THEN quote_ident($2) ELSE quote_ident($1) || '.' || quote_ident($2) END)`
+
+// GetTableInfo читает метаданные таблиц. Таблицы, которых нет в базе
+// (временные, CTE, опечатки), пропускаются.
+func GetTableInfo(ctx context.Context, client db.DB, refs []sqlfiles.TableRef) ([]models.TableInfo, error) {
+	var tables []models.TableInfo
+	for _, ref := range refs {
+		info, found, err := getTableInfo(ctx, client, ref)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			tables = append(tables, info)
+		}
+	}
+	return tables, nil
+}
+
+func getTableInfo(ctx context.Context, client db.DB, ref sqlfiles.TableRef) (models.TableInfo, bool, error) {
+	var info models.TableInfo
+
+	rows, err := client.QueryContext(ctx, db.Query{Name: "get_table_info", Raw: tableInfoQuery}, ref.Schema, ref.Name)
+	if err != nil {
+		return info, false, fmt.Errorf("failed to query table info for %s: %w", ref, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return info, false, rows.Err()
+	}
+
+	var columns string
+	if err := rows.Scan(&info.Schema, &info.Name, &info.RowCount, &info.SizeBytes,
+		&info.SeqScan, &info.IdxScan, &info.Indexes, &columns); err != nil {
+		return info, false, fmt.Errorf("failed to scan table info for %s: %w", ref, err)
+	}
+	if err := json.Unmarshal([]byte(columns), &info.Columns); err != nil {
+		return info, false, fmt.Errorf("failed to parse columns of %s: %w", ref, err)
+	}
+
+	return info, true, rows.Err()
+}
+
+// TableInfoEnricher дополняет запросы на ревью метаданными таблиц, на которые ссылается SQL.
+// Метаданные кешируются на время жизни энричера, чтобы не запрашивать одну таблицу повторно.
+type TableInfoEnricher struct {
+	client db.DB
+	cache  map[sqlfiles.TableRef]*models.TableInfo
+}
+
+// NewTableInfoEnricher создает энричер поверх подключения к целевой базе
+func NewTableInfoEnricher(client db.DB) *TableInfoEnricher {
+	return &TableInfoEnricher{
+		client: client,
+		cache:  make(map[sqlfiles.TableRef]*models.TableInfo),
+	}
+}
+
+// EnrichQuery заполняет req.Tables. Ошибки пишутся в лог: ревью выполняется и без метаданных.
+func (e *TableInfoEnricher) EnrichQuery(ctx context.Context, f sqlfiles.SQLFile, req *models.QueryReviewRequest) {
+	for _, ref := range sqlfiles.ReferencedTables(req.SQL) {
+		info, cached := e.cache[ref]
+		if !cached {
+			table, found, err := getTableInfo(ctx, e.client, ref)
+			if err != nil {
+				log.Printf("⚠️ %s: %v", f.Path, err)
+				continue
+			}
+			if found {
+				info = &table
+			}
+			e.cache[ref] = info
+		}
+		if info != nil {
+			req.Tables = append(req.Tables, *info)
+		}
+	}
+}
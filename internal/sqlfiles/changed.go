@@ -0,0 +1,148 @@
+package sqlfiles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gitdiff"
+)
+
+// collectChangedFiles собирает SQL-файлы, добавленные или изменённые относительно config.BaseRef.
+// Для изменённых обычных файлов заполняется ChangedLines, чтобы ревьюить только изменённые операторы;
+// миграции ревьюятся целиком.
+func collectChangedFiles(config SearchConfig) ([]SQLFile, error) {
+	if config.BaseRef == "" {
+		return nil, fmt.Errorf("base git ref is required")
+	}
+
+	root, err := filepath.Abs(config.RootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", config.RootPath, err)
+	}
+	filter, err := newPathFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := gitdiff.Changed(context.Background(), config.RootPath, config.BaseRef, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make(map[string][]string)
+	var files []SQLFile
+	var migrations []migrationFile
+	for _, c := range changed {
+		rel, err := filepath.Rel(root, c.Path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if filter.skipFile(filepath.ToSlash(rel)) {
+			continue
+		}
+
+		content, err := os.ReadFile(c.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(config.RootPath, rel)
+		file := SQLFile{
+			Title:   makeUniqueTitle(files, filepath.Base(path), path),
+			Content: string(content),
+			Path:    path,
+		}
+		if c.Status == gitdiff.Modified {
+			file.ChangedLines = append([]gitdiff.LineRange{}, c.Lines...)
+		}
+
+		if !isMigrationPath(config, path) {
+			files = append(files, file)
+			continue
+		}
+
+		// Соглашения sqitch определяются по абсолютному пути относительно каталога миграций
+		migrationsPath := changedMigrationsDir(config, c.Path)
+		plan, ok := plans[migrationsPath]
+		if !ok {
+			if plan, err = sqitchPlan(migrationsPath); err != nil {
+				return nil, err
+			}
+			plans[migrationsPath] = plan
+		}
+
+		file.IsMigration = true
+		file.Path = c.Path
+		if f, ok := newMigrationFile(migrationsPath, plan, file); ok {
+			f.Path = path
+			migrations = append(migrations, f)
+		}
+	}
+
+	return append(files, orderMigrations(migrations)...), nil
+}
+
+// changedMigrationsDir возвращает абсолютный путь каталога миграций, в котором лежит файл:
+// заданный в конфигурации или ближайший каталог с именем migrations
+func changedMigrationsDir(config SearchConfig, path string) string {
+	if config.MigrationsPath != "" || len(config.MigrationsDirs) > 0 {
+		for _, dir := range migrationDirs(config) {
+			if isWithin(dir, path) {
+				abs, _ := filepath.Abs(dir)
+				return abs
+			}
+		}
+	}
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if strings.EqualFold(filepath.Base(dir), "migrations") {
+			return dir
+		}
+	}
+	return filepath.Dir(path)
+}
+
+// CheckChangedMigrations проверяет изменённые миграции относительно уже существующих:
+// изменение применённой миграции и новая миграция с версией ниже существующих
+// (такая миграция будет пропущена или применится не по порядку)
+func CheckChangedMigrations(changed, existing []SQLFile) []MigrationIssue {
+	changedPaths := make(map[string]bool, len(changed))
+	for _, f := range changed {
+		changedPaths[filepath.Clean(f.Path)] = true
+	}
+
+	latest := make(map[MigrationTool]SQLFile)
+	for _, f := range existing {
+		m := f.Migration
+		if m == nil || m.Version == "" || m.Direction != DirectionUp || m.Tool == ToolSqitch || changedPaths[filepath.Clean(f.Path)] {
+			continue
+		}
+		if prev, ok := latest[m.Tool]; !ok || CompareVersions(m.Version, prev.Migration.Version) > 0 {
+			latest[m.Tool] = f
+		}
+	}
+
+	var issues []MigrationIssue
+	for _, f := range changed {
+		m := f.Migration
+		if !f.IsMigration || m == nil {
+			continue
+		}
+		if f.ChangedLines != nil && m.Direction != DirectionRepeatable {
+			issues = append(issues, MigrationIssue{
+				Path:    f.Path,
+				Message: "existing migration was modified: applied migrations are not re-run, add a new migration instead",
+			})
+			continue
+		}
+		if prev, ok := latest[m.Tool]; ok && m.Version != "" && CompareVersions(m.Version, prev.Migration.Version) < 0 {
+			issues = append(issues, MigrationIssue{
+				Path:    f.Path,
+				Message: fmt.Sprintf("new %s migration version %s is older than existing %s, it will run out of order", m.Tool, m.Version, filepath.Base(prev.Path)),
+			})
+		}
+	}
+	return issues
+}
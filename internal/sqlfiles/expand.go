@@ -0,0 +1,382 @@
+package sqlfiles
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// maxIncludeDepth глубина вложенности \i, после которой подключение считается зацикленным
+const maxIncludeDepth = 16
+
+// SourceLine исходное положение строки развёрнутого файла
+type SourceLine struct {
+	Path string // Файл, из которого взята строка: сам файл или подключённый через \i
+	Line int    // Строка в этом файле
+	Top  int    // Строка исходного файла; для подключённых строк — строка с \i
+}
+
+var (
+	includePattern = regexp.MustCompile(`^\s*\\(i|ir|include|include_relative)\s+('[^']*'|\S+)\s*$`)
+	setPattern     = regexp.MustCompile(`^\s*\\set\s+([A-Za-z_][A-Za-z0-9_]*)(?:\s+(.*?))?\s*$`)
+	varNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	varPattern     = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*=(.*)$`)
+	dollarPattern  = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+	// templateAction начало действия text/template: {{.name}}, {{if ...}}, {{end}} и т.п.
+	// Литералы вроде '{{1,2},{3,4}}' (многомерные массивы) шаблоном не считаются.
+	templateAction = regexp.MustCompile(`\{\{-?\s*(?:\.|\$|/\*|(?:if|else|end|range|with|template|block|define)\b)`)
+)
+
+// Origin возвращает файл и строку, из которых взята строка line развёрнутого содержимого
+func (f SQLFile) Origin(line int) (string, int) {
+	if line < 1 || line > len(f.Source) {
+		return f.Path, line
+	}
+	s := f.Source[line-1]
+	return s.Path, s.Line
+}
+
+// TopLine возвращает строку исходного файла, к которой относится строка line развёрнутого содержимого
+func (f SQLFile) TopLine(line int) int {
+	if line < 1 || line > len(f.Source) {
+		return line
+	}
+	return f.Source[line-1].Top
+}
+
+// ParseVar разбирает переменную в формате key=value
+func ParseVar(s string) (string, string, error) {
+	m := varPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value", s)
+	}
+	return m[1], m[2], nil
+}
+
+// LoadVars читает переменные из файла: строки key=value, пустые строки и комментарии # пропускаются
+func LoadVars(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vars file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := ParseVar(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vars file: %w", err)
+	}
+	return vars, nil
+}
+
+// ExpandFiles разворачивает файлы перед ревью: подключает \i и \ir, подставляет переменные psql
+// (:name, :'name', :"name") и плейсхолдеры text/template ({{.name}}). Для изменённых файлов
+// заполняется SQLFile.Source, чтобы результаты ревью указывали на исходные файлы и строки.
+// Ошибка развёртывания сохраняется в SQLFile.ExpandErr файла и не мешает остальным файлам.
+func ExpandFiles(files []SQLFile, vars map[string]string) {
+	for i := range files {
+		files[i].ExpandErr = expandFile(&files[i], vars)
+	}
+}
+
+// expandFile разворачивает файл и его откат; при ошибке файл не изменяется
+func expandFile(f *SQLFile, vars map[string]string) error {
+	e := newExpander(vars)
+	if err := e.expand(f.Path, f.Content, 0); err != nil {
+		return fmt.Errorf("failed to expand %s: %w", f.Path, err)
+	}
+
+	// Откат разворачивается с теми же переменными; строки отката в отчётах не используются
+	down := ""
+	if m := f.Migration; m != nil && m.Down != "" {
+		path := m.DownPath
+		if path == "" {
+			path = f.Path
+		}
+		d := newExpander(vars)
+		if err := d.expand(path, m.Down, 0); err != nil {
+			return fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+		if d.changed {
+			down = strings.Join(d.out, "\n")
+		}
+	}
+
+	if e.changed {
+		f.Content = strings.Join(e.out, "\n")
+		f.Source = e.source
+	}
+	if down != "" {
+		f.Migration.Down = down
+	}
+	return nil
+}
+
+// expander разворачивает файл построчно. Состояние разбора (строки, комментарии)
+// сохраняется между строками, чтобы не подставлять переменные внутри литералов.
+type expander struct {
+	vars    map[string]string
+	stack   []string
+	out     []string
+	source  []SourceLine
+	changed bool
+	state   scanState
+}
+
+// scanState положение разбора внутри литерала или комментария
+type scanState struct {
+	quote  byte   // ' или ", если разбор внутри строки или идентификатора
+	escape bool   // E'...' строка с экранированием обратным слешем
+	depth  int    // Вложенность блочных комментариев
+	dollar string // Тег dollar-quoted строки
+}
+
+func newExpander(vars map[string]string) *expander {
+	e := &expander{vars: make(map[string]string, len(vars))}
+	for k, v := range vars {
+		e.vars[k] = v
+	}
+	return e
+}
+
+// expand разворачивает содержимое файла path; top — строка исходного файла с \i
+// или 0 для самого исходного файла
+func (e *expander) expand(path, content string, top int) error {
+	for _, p := range e.stack {
+		if p == path {
+			return fmt.Errorf("include cycle: %s", strings.Join(append(e.stack, path), " -> "))
+		}
+	}
+	if len(e.stack) >= maxIncludeDepth {
+		return fmt.Errorf("includes are nested deeper than %d levels", maxIncludeDepth)
+	}
+	e.stack = append(e.stack, path)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+
+	content, err := e.template(path, content)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		lineTop := top
+		if top == 0 {
+			lineTop = i + 1
+		}
+
+		if e.state.normal() {
+			if m := includePattern.FindStringSubmatch(line); m != nil {
+				if err := e.include(path, m[1], strings.Trim(m[2], "'"), lineTop); err != nil {
+					return fmt.Errorf("%s:%d: %w", path, i+1, err)
+				}
+				continue
+			}
+			if m := setPattern.FindStringSubmatch(line); m != nil {
+				e.vars[m[1]] = unquoteSetValue(m[2])
+			}
+		}
+
+		for _, l := range strings.Split(e.substitute(line), "\n") {
+			e.out = append(e.out, l)
+			e.source = append(e.source, SourceLine{Path: path, Line: i + 1, Top: lineTop})
+		}
+	}
+	return nil
+}
+
+// include подключает файл: \ir и \include_relative относительно подключающего файла,
+// \i и \include — относительно него же, а если там файла нет — относительно рабочего каталога
+func (e *expander) include(from, command, target string, top int) error {
+	path := target
+	if !filepath.IsAbs(target) {
+		path = filepath.Join(filepath.Dir(from), target)
+		if _, err := os.Stat(path); err != nil && (command == "i" || command == "include") {
+			if _, err := os.Stat(target); err == nil {
+				path = target
+			}
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to include %s: %w", target, err)
+	}
+	e.changed = true
+	return e.expand(path, strings.TrimSuffix(string(content), "\n"), top)
+}
+
+// template выполняет плейсхолдеры text/template, если в файле есть действие шаблона.
+// Плейсхолдеры должны стоять внутри строк: блоки {{if}}/{{range}}, меняющие число строк,
+// сдвигают номера строк в отчётах.
+func (e *expander) template(path, content string) (string, error) {
+	if !templateAction.MatchString(content) {
+		return content, nil
+	}
+
+	t, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, e.vars); err != nil {
+		return "", fmt.Errorf("failed to execute template (set variables with --var): %w", err)
+	}
+	if b.String() != content {
+		e.changed = true
+	}
+	return b.String(), nil
+}
+
+func (s scanState) normal() bool {
+	return s.quote == 0 && s.depth == 0 && s.dollar == ""
+}
+
+// substitute подставляет переменные psql вне строк, идентификаторов в кавычках и комментариев.
+// Неизвестные переменные остаются как есть, как и в psql; :: — приведение типа.
+func (e *expander) substitute(line string) string {
+	var b strings.Builder
+	s := &e.state
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case s.dollar != "":
+			if strings.HasPrefix(line[i:], s.dollar) {
+				b.WriteString(s.dollar)
+				i += len(s.dollar)
+				s.dollar = ""
+				continue
+			}
+		case s.depth > 0:
+			switch {
+			case strings.HasPrefix(line[i:], "/*"):
+				s.depth++
+				b.WriteString("/*")
+				i += 2
+				continue
+			case strings.HasPrefix(line[i:], "*/"):
+				s.depth--
+				b.WriteString("*/")
+				i += 2
+				continue
+			}
+		case s.quote != 0:
+			switch {
+			case s.escape && c == '\\' && i+1 < len(line):
+				b.WriteString(line[i : i+2])
+				i += 2
+				continue
+			case c == s.quote && i+1 < len(line) && line[i+1] == s.quote:
+				b.WriteString(line[i : i+2])
+				i += 2
+				continue
+			case c == s.quote:
+				s.quote, s.escape = 0, false
+			}
+		default:
+			switch {
+			case strings.HasPrefix(line[i:], "--"):
+				b.WriteString(line[i:])
+				return b.String()
+			case strings.HasPrefix(line[i:], "/*"):
+				s.depth = 1
+				b.WriteString("/*")
+				i += 2
+				continue
+			case strings.HasPrefix(line[i:], "::"):
+				b.WriteString("::")
+				i += 2
+				continue
+			case c == '\'':
+				s.quote = c
+				s.escape = i > 0 && (line[i-1] == 'e' || line[i-1] == 'E') && (i < 2 || !isIdentByte(line[i-2]))
+			case c == '"':
+				s.quote = c
+			case c == '$' && (i == 0 || !isIdentByte(line[i-1])):
+				if tag := dollarPattern.FindString(line[i:]); tag != "" {
+					s.dollar = tag
+					b.WriteString(tag)
+					i += len(tag)
+					continue
+				}
+			case c == ':':
+				if value, n, ok := e.variable(line[i:]); ok {
+					b.WriteString(value)
+					i += n
+					e.changed = true
+					continue
+				}
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// variable разбирает ссылку на переменную в начале s: :name, :'name' или :"name".
+// Возвращает подставляемое значение и длину ссылки.
+func (e *expander) variable(s string) (string, int, bool) {
+	if len(s) < 2 {
+		return "", 0, false
+	}
+	quote := s[1]
+	if quote == '\'' || quote == '"' {
+		end := strings.IndexByte(s[2:], quote)
+		if end < 0 {
+			return "", 0, false
+		}
+		value, ok := e.vars[s[2:2+end]]
+		if !ok {
+			return "", 0, false
+		}
+		if quote == '\'' {
+			return "'" + strings.ReplaceAll(value, "'", "''") + "'", end + 3, true
+		}
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`, end + 3, true
+	}
+
+	name := varNamePattern.FindString(s[1:])
+	value, ok := e.vars[name]
+	if name == "" || !ok {
+		return "", 0, false
+	}
+	return value, len(name) + 1, true
+}
+
+// unquoteSetValue возвращает значение \set: части в одинарных кавычках склеиваются без кавычек
+func unquoteSetValue(value string) string {
+	if !strings.Contains(value, "'") {
+		return strings.Join(strings.Fields(value), "")
+	}
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(value) && value[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+		case !inQuote && (c == ' ' || c == '\t'):
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package sqlfiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{"tbl": "orders", "name": "it's", "col": "Total"}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "select * from :tbl;", "select * from orders;"},
+		{"literal", "select :'name';", "select 'it''s';"},
+		{"identifier", `select :"col" from t;`, `select "Total" from t;`},
+		{"cast is not a variable", "select 1::int, :tbl;", "select 1::int, orders;"},
+		{"unknown variable", "select :missing;", "select :missing;"},
+		{"inside string", "select ':tbl';", "select ':tbl';"},
+		{"inside comment", "select 1; -- :tbl\n/* :tbl\n:tbl */ select :tbl;", "select 1; -- :tbl\n/* :tbl\n:tbl */ select orders;"},
+		{"inside dollar quote", "do $$ begin perform :tbl; end $$;", "do $$ begin perform :tbl; end $$;"},
+		{"multiline string", "select 'a\n:tbl';\nselect :tbl;", "select 'a\n:tbl';\nselect orders;"},
+		{"set in file", "\\set lim 10\nselect 1 limit :lim;", "\\set lim 10\nselect 1 limit 10;"},
+		{"set with quotes", "\\set v 'a b'\nselect :'v';", "\\set v 'a b'\nselect 'a b';"},
+		{"template", "select '{{.tbl}}';", "select 'orders';"},
+		{"array literal is not a template", "select '{{1,2},{3,4}}'::int[];", "select '{{1,2},{3,4}}'::int[];"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []SQLFile{{Path: "q.sql", Content: tt.content}}
+			ExpandFiles(files, vars)
+			if files[0].ExpandErr != nil {
+				t.Fatal(files[0].ExpandErr)
+			}
+			if files[0].Content != tt.want {
+				t.Errorf("got %q, want %q", files[0].Content, tt.want)
+			}
+			if tt.content == tt.want && files[0].Source != nil {
+				t.Error("unchanged file should have no source map")
+			}
+		})
+	}
+}
+
+func TestExpandIncludesSourceMap(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	write("inc/part.sql", "select 1;\n\\ir nested.sql\n")
+	write("inc/nested.sql", "select :n;\n")
+	main := write("main.sql", "begin;\n\\i inc/part.sql\ncommit;")
+
+	files := []SQLFile{{Path: main, Content: "begin;\n\\i inc/part.sql\ncommit;"}}
+	ExpandFiles(files, map[string]string{"n": "2"})
+	f := files[0]
+	if f.ExpandErr != nil {
+		t.Fatal(f.ExpandErr)
+	}
+
+	if want := "begin;\nselect 1;\nselect 2;\ncommit;"; f.Content != want {
+		t.Fatalf("got %q, want %q", f.Content, want)
+	}
+	part, nested := filepath.Join(dir, "inc/part.sql"), filepath.Join(dir, "inc/nested.sql")
+	want := []SourceLine{
+		{Path: main, Line: 1, Top: 1},
+		{Path: part, Line: 1, Top: 2},
+		{Path: nested, Line: 1, Top: 2},
+		{Path: main, Line: 3, Top: 3},
+	}
+	if !reflect.DeepEqual(f.Source, want) {
+		t.Errorf("source map:\n got %+v\nwant %+v", f.Source, want)
+	}
+
+	if path, line := f.Origin(3); path != nested || line != 1 {
+		t.Errorf("Origin(3) = %s:%d, want %s:1", path, line, nested)
+	}
+	if top := f.TopLine(4); top != 3 {
+		t.Errorf("TopLine(4) = %d, want 3", top)
+	}
+	if path, line := f.Origin(10); path != main || line != 10 {
+		t.Errorf("Origin out of range = %s:%d, want the line itself", path, line)
+	}
+}
+
+func TestExpandErrorsArePerFile(t *testing.T) {
+	dir := t.TempDir()
+	cycle := filepath.Join(dir, "cycle.sql")
+	if err := os.WriteFile(cycle, []byte("\\i cycle.sql"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []SQLFile{
+		{Path: filepath.Join(dir, "missing_var.sql"), Content: "select * from {{.schema}}.t;"},
+		{Path: cycle, Content: "\\i cycle.sql"},
+		{Path: filepath.Join(dir, "missing_include.sql"), Content: "\\i nope.sql"},
+		{Path: filepath.Join(dir, "bad_template.sql"), Content: "select '{{if .x}}';"},
+		{Path: filepath.Join(dir, "ok.sql"), Content: "select :v;"},
+	}
+	ExpandFiles(files, map[string]string{"v": "1"})
+
+	for i, want := range []string{"failed to execute template", "include cycle", "failed to include nope.sql", "failed to parse template"} {
+		f := files[i]
+		if f.ExpandErr == nil || !strings.Contains(f.ExpandErr.Error(), want) {
+			t.Errorf("%s: got %v, want an error containing %q", filepath.Base(f.Path), f.ExpandErr, want)
+		}
+		if !strings.Contains(f.ExpandErr.Error(), f.Path) {
+			t.Errorf("%s: error %v does not name the file", filepath.Base(f.Path), f.ExpandErr)
+		}
+	}
+	if f := files[4]; f.ExpandErr != nil || f.Content != "select 1;" {
+		t.Errorf("ok.sql: got %q, %v", f.Content, f.ExpandErr)
+	}
+}
+
+func TestExpandMigrationRollback(t *testing.T) {
+	files := []SQLFile{{
+		Path:      "001_t.up.sql",
+		Content:   "create table :tbl ();",
+		Migration: &Migration{Down: "drop table :tbl;"},
+	}}
+	ExpandFiles(files, map[string]string{"tbl": "t"})
+	if f := files[0]; f.Content != "create table t ();" || f.Migration.Down != "drop table t;" {
+		t.Errorf("got %q and rollback %q", f.Content, f.Migration.Down)
+	}
+
+	// Ошибка в откате не меняет ни миграцию, ни откат
+	files = []SQLFile{{
+		Path:      "001_t.up.sql",
+		Content:   "create table :tbl ();",
+		Migration: &Migration{Down: "drop table {{.missing}};", DownPath: "001_t.down.sql"},
+	}}
+	ExpandFiles(files, map[string]string{"tbl": "t"})
+	f := files[0]
+	if f.ExpandErr == nil || !strings.Contains(f.ExpandErr.Error(), "001_t.down.sql") {
+		t.Errorf("got %v, want an error for the rollback file", f.ExpandErr)
+	}
+	if f.Content != "create table :tbl ();" || f.Source != nil {
+		t.Errorf("migration was changed despite the error: %q", f.Content)
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	if k, v, err := ParseVar(" schema = app=1"); err != nil || k != "schema" || v != " app=1" {
+		t.Errorf("got %q %q %v", k, v, err)
+	}
+	if _, _, err := ParseVar("1x=2"); err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+}
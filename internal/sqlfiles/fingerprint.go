@@ -0,0 +1,299 @@
+package sqlfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// valueListPattern списки литералов вида (?,?,?) после нормализации
+var valueListPattern = regexp.MustCompile(`\(\?(?:,\?)*\)`)
+
+// Fingerprint возвращает отпечаток SQL-запроса: хеш нормализованного текста,
+// одинаковый для запросов, отличающихся только литералами, параметрами,
+// комментариями, регистром ключевых слов и пробелами
+func Fingerprint(sql string) string {
+	sum := sha256.Sum256([]byte(NormalizeQuery(sql)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NormalizeQuery приводит запрос к виду для сравнения: литералы и параметры
+// заменяются на ?, комментарии удаляются, пробелы остаются только между словами,
+// текст вне идентификаторов в двойных кавычках приводится к нижнему регистру
+func NormalizeQuery(sql string) string {
+	var b strings.Builder
+	src := []rune(sql)
+	n := len(src)
+	space := false
+
+	// Пробел сохраняется только между словами, чтобы "id=1" и "id = 1" совпадали
+	last := rune(0)
+	emit := func(s string) {
+		first := []rune(s)[0]
+		if space && wordRune(last) && wordRune(first) {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+		last = []rune(s)[len([]rune(s))-1]
+	}
+
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(c):
+			space = true
+			i++
+
+		case c == '-' && i+1 < n && src[i+1] == '-':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+			space = true
+
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			i = skipComment(src, i)
+			space = true
+
+		case c == '\'' || ((c == 'e' || c == 'E') && i+1 < n && src[i+1] == '\'' && !identRune(prev(src, i))):
+			escapes := c != '\''
+			if escapes {
+				i++
+			}
+			i = skipQuoted(src, i+1, '\'', escapes)
+			emit("?")
+
+		case c == '"':
+			end := skipQuoted(src, i+1, '"', false)
+			emit(string(src[i:end]))
+			i = end
+
+		case c == '$' && i+1 < n && unicode.IsDigit(src[i+1]):
+			i++
+			for i < n && unicode.IsDigit(src[i]) {
+				i++
+			}
+			emit("?")
+
+		case c == '$':
+			if tag, ok := dollarTag(src, i); ok {
+				i = skipDollarQuoted(src, i+len(tag), tag)
+				emit("?")
+				continue
+			}
+			emit("$")
+			i++
+
+		case unicode.IsDigit(c) && !identRune(prev(src, i)):
+			for i < n && (unicode.IsDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E') {
+				i++
+			}
+			emit("?")
+
+		case identRune(c):
+			start := i
+			for i < n && (identRune(src[i]) || src[i] == '$') {
+				i++
+			}
+			emit(strings.ToLower(string(src[start:i])))
+
+		default:
+			emit(string(unicode.ToLower(c)))
+			i++
+		}
+	}
+
+	return valueListPattern.ReplaceAllString(b.String(), "(?)")
+}
+
+// HasParameters сообщает, есть ли в запросе позиционные параметры $1, $2, ...
+// вне строк, комментариев и идентификаторов в кавычках
+func HasParameters(sql string) bool {
+	src := []rune(sql)
+	n := len(src)
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case c == '-' && i+1 < n && src[i+1] == '-':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			i = skipComment(src, i)
+		case c == '\'' || ((c == 'e' || c == 'E') && i+1 < n && src[i+1] == '\'' && !identRune(prev(src, i))):
+			escapes := c != '\''
+			if escapes {
+				i++
+			}
+			i = skipQuoted(src, i+1, '\'', escapes)
+		case c == '"':
+			i = skipQuoted(src, i+1, '"', false)
+		case c == '$' && i+1 < n && unicode.IsDigit(src[i+1]):
+			return true
+		case c == '$':
+			if tag, ok := dollarTag(src, i); ok {
+				i = skipDollarQuoted(src, i+len(tag), tag)
+				continue
+			}
+			i++
+		case identRune(c):
+			for i < n && (identRune(src[i]) || src[i] == '$') {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return false
+}
+
+// MaskQuery заменяет пробелами комментарии и содержимое строковых литералов,
+// оставляя кавычки и теги dollar-quoted строк. Смещения в байтах сохраняются,
+// поэтому позиция совпадения в маске указывает на то же место исходного текста.
+func MaskQuery(sql string) string {
+	var b strings.Builder
+	src := []rune(sql)
+	n := len(src)
+	blank := func(from, to int) {
+		b.WriteString(strings.Repeat(" ", len(string(src[from:to]))))
+	}
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case c == '-' && i+1 < n && src[i+1] == '-':
+			start := i
+			for i < n && src[i] != '\n' {
+				i++
+			}
+			blank(start, i)
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			start := i
+			i = skipComment(src, i)
+			blank(start, i)
+		case c == '\'' || ((c == 'e' || c == 'E') && i+1 < n && src[i+1] == '\'' && !identRune(prev(src, i))):
+			escapes := c != '\''
+			if escapes {
+				b.WriteRune(c)
+				i++
+			}
+			b.WriteRune('\'')
+			end := skipQuoted(src, i+1, '\'', escapes)
+			if end > i+1 && src[end-1] == '\'' {
+				blank(i+1, end-1)
+				b.WriteRune('\'')
+			} else {
+				blank(i+1, end)
+			}
+			i = end
+		case c == '"':
+			end := skipQuoted(src, i+1, '"', false)
+			b.WriteString(string(src[i:end]))
+			i = end
+		case c == '$':
+			tag, ok := dollarTag(src, i)
+			if !ok {
+				b.WriteRune(c)
+				i++
+				continue
+			}
+			b.WriteString(string(tag))
+			end := skipDollarQuoted(src, i+len(tag), tag)
+			if end-len(tag) >= i+len(tag) && string(src[end-len(tag):end]) == string(tag) {
+				blank(i+len(tag), end-len(tag))
+				b.WriteString(string(tag))
+			} else {
+				blank(i+len(tag), end)
+			}
+			i = end
+		case identRune(c):
+			start := i
+			for i < n && (identRune(src[i]) || src[i] == '$') {
+				i++
+			}
+			b.WriteString(string(src[start:i]))
+		default:
+			b.WriteRune(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipComment возвращает позицию после блочного комментария с учётом вложенности
+func skipComment(src []rune, i int) int {
+	depth := 0
+	for i < len(src) {
+		if src[i] == '/' && i+1 < len(src) && src[i+1] == '*' {
+			depth++
+			i += 2
+		} else if src[i] == '*' && i+1 < len(src) && src[i+1] == '/' {
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		} else {
+			i++
+		}
+	}
+	return i
+}
+
+// skipQuoted возвращает позицию после закрывающей кавычки.
+// Удвоенная кавычка считается экранированной; в E-строках также обратный слеш.
+func skipQuoted(src []rune, i int, quote rune, backslash bool) int {
+	for i < len(src) {
+		switch {
+		case backslash && src[i] == '\\':
+			i += 2
+		case src[i] == quote && i+1 < len(src) && src[i+1] == quote:
+			i += 2
+		case src[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipDollarQuoted возвращает позицию после закрывающего тега
+func skipDollarQuoted(src []rune, i int, tag []rune) int {
+	for ; i+len(tag) <= len(src); i++ {
+		if string(src[i:i+len(tag)]) == string(tag) {
+			return i + len(tag)
+		}
+	}
+	return len(src)
+}
+
+// dollarTag разбирает открывающий тег dollar-quoted строки ($$ или $tag$)
+func dollarTag(src []rune, i int) ([]rune, bool) {
+	for j := i + 1; j < len(src); j++ {
+		if src[j] == '$' {
+			return src[i : j+1], true
+		}
+		if !identRune(src[j]) || (j == i+1 && unicode.IsDigit(src[j])) {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func identRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// wordRune сообщает, является ли символ частью слова, литерала или идентификатора
+func wordRune(c rune) bool {
+	return identRune(c) || c == '?' || c == '"'
+}
+
+func prev(src []rune, i int) rune {
+	if i == 0 {
+		return 0
+	}
+	return src[i-1]
+}
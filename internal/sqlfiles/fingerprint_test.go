@@ -0,0 +1,73 @@
+package sqlfiles
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasParameters(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"select * from t where id = $1", true},
+		{"select * from t where id = $12 and x = $2", true},
+		{"select * from t where id = 1", false},
+		{"select '$1' from t", false},
+		{`select E'\'$1' from t`, false},
+		{`select "$1" from t`, false},
+		{"select 1 -- where id = $1", false},
+		{"select /* $1 /* nested */ $2 */ 1", false},
+		{"select $$ $1 $$, $tag$ $2 $tag$", false},
+		{"select a$1 from t", false},
+		{"select $$x$$ from t where id = $1", true},
+	}
+	for _, tt := range tests {
+		if got := HasParameters(tt.sql); got != tt.want {
+			t.Errorf("HasParameters(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT  id\nFROM t WHERE id = $1", "select id from t where id=?"},
+		{"select 'a', E'b\\'c', 42, 1.5e3 from t", "select ?,?,?,? from t"},
+		{`select "Name" from /* x /* y */ z */ t -- tail`, `select "Name" from t`},
+		{"select * from t where id in (1, 2, 3)", "select*from t where id in(?)"},
+		{"insert into t values (1, 2), (3, 4)", "insert into t values(?),(?)"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeQuery(tt.sql); got != tt.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestMaskQuery(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"select id from t offset 5000", "select id from t offset 5000"},
+		{"select 1 -- offset 5000\nfrom t", "select 1 " + strings.Repeat(" ", len("-- offset 5000")) + "\nfrom t"},
+		{"select /* a /* b */ c */ 1", "select                   1"},
+		{"select 'offset 5000', E'a\\'b' from t", "select '           ', E'    ' from t"},
+		{"select $$like '%x'$$, $t$ ; $t$", "select $$" + strings.Repeat(" ", len("like '%x'")) + "$$, $t$   $t$"},
+		{`select "Offset" from t where a = $1`, `select "Offset" from t where a = $1`},
+		{"select 'ё%', 1", "select '" + strings.Repeat(" ", len("ё%")) + "', 1"},
+		{"select 'unterminated", "select '" + strings.Repeat(" ", len("unterminated"))},
+	}
+	for _, tt := range tests {
+		got := MaskQuery(tt.sql)
+		if got != tt.want {
+			t.Errorf("MaskQuery(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+		if len(got) != len(tt.sql) {
+			t.Errorf("MaskQuery(%q) changed length from %d to %d", tt.sql, len(tt.sql), len(got))
+		}
+	}
+}
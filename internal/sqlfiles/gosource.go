@@ -0,0 +1,267 @@
+package sqlfiles
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// goQueryMethods методы database/sql (и клиентов с тем же API) и индекс аргумента с текстом запроса
+var goQueryMethods = map[string]int{
+	"Query":           0,
+	"QueryContext":    1,
+	"QueryRow":        0,
+	"QueryRowContext": 1,
+	"Exec":            0,
+	"ExecContext":     1,
+	"Prepare":         0,
+	"PrepareContext":  1,
+}
+
+// goQueryField поле структуры с текстом запроса, например db.Query{Raw: ...}
+const goQueryField = "Raw"
+
+// sqlStartPattern начало текста, похожего на SQL: строки с другими данными не ревьюятся
+var sqlStartPattern = regexp.MustCompile(`(?is)^\s*(?:(?:--[^\n]*\n|/\*.*?\*/)\s*)*(?:select|insert|update|delete|with|create|alter|drop|truncate|grant|revoke|do|call|explain|copy|vacuum|analyze|refresh|lock|merge|set|show|values|table|comment|reindex|cluster)\b`)
+
+// goPackage файлы одного каталога с Go-кодом
+type goPackage struct {
+	paths []string
+}
+
+// goQuery SQL, найденный в Go-коде
+type goQuery struct {
+	sql   string
+	line  int  // Строка начала литерала или выражения
+	exact bool // Строки SQL совпадают со строками файла (raw-литерал `...`)
+}
+
+// collectGoFiles собирает SQL из строковых констант Go-кода: аргументы QueryContext, ExecContext,
+// QueryRowContext и их вариантов без контекста, а также поля Raw. Константы вычисляются
+// через go/types, поэтому учитываются именованные константы и конкатенация; SQL, собранный
+// во время выполнения (fmt.Sprintf, переменные), пропускается.
+func collectGoFiles(config SearchConfig) ([]SQLFile, error) {
+	filter, err := newPathFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*goPackage
+	byDir := make(map[string]*goPackage)
+	err = walkFiles(config, filter, config.RootPath, ".go", func(path string) error {
+		if strings.HasSuffix(path, "_test.go") || inGoIgnoredDir(relPath(config.RootPath, config.RootPath, path)) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		pkg, ok := byDir[dir]
+		if !ok {
+			pkg = &goPackage{}
+			byDir[dir] = pkg
+			packages = append(packages, pkg)
+		}
+		pkg.paths = append(pkg.paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []SQLFile
+	for _, pkg := range packages {
+		found, err := pkg.queries()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range found {
+			f.Title = makeUniqueTitle(files, f.Title, f.Path)
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// queries разбирает файлы каталога и возвращает найденные запросы в порядке появления
+func (p *goPackage) queries() ([]SQLFile, error) {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, path := range p.paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			// Файл с синтаксической ошибкой не мешает остальным
+			continue
+		}
+		parsed = append(parsed, f)
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	// Зависимости не загружаются: ошибки типов игнорируются, значения констант пакета вычисляются
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: stubImporter{}, Error: func(error) {}}
+	_, _ = conf.Check(parsed[0].Name.Name, fset, parsed, info)
+
+	consts := make(map[types.Object]ast.Expr)
+	for _, f := range parsed {
+		ast.Inspect(f, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok {
+				return true
+			}
+			for i, name := range spec.Names {
+				if obj, ok := info.Defs[name].(*types.Const); ok && i < len(spec.Values) {
+					consts[obj] = spec.Values[i]
+				}
+			}
+			return true
+		})
+	}
+
+	var files []SQLFile
+	for _, f := range parsed {
+		path := fset.File(f.Pos()).Name()
+		seen := make(map[goQuery]bool)
+
+		add := func(expr ast.Expr) {
+			q, ok := goQueryAt(fset, info, consts, expr)
+			if !ok || seen[q] {
+				return
+			}
+			seen[q] = true
+			files = append(files, newGoSQLFile(path, q))
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if i, ok := goQueryMethods[sel.Sel.Name]; ok && i < len(n.Args) {
+					add(n.Args[i])
+				}
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok && key.Name == goQueryField {
+					add(n.Value)
+				}
+			}
+			return true
+		})
+	}
+
+	return files, nil
+}
+
+// goQueryAt возвращает SQL строковой константы expr и строку, на которую указывают результаты:
+// сам литерал или объявление константы, если запрос задан именованной константой
+func goQueryAt(fset *token.FileSet, info *types.Info, consts map[types.Object]ast.Expr, expr ast.Expr) (goQuery, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return goQuery{}, false
+	}
+	sql := constant.StringVal(tv.Value)
+	if !sqlStartPattern.MatchString(sql) {
+		return goQuery{}, false
+	}
+
+	anchor := expr
+	for {
+		switch e := anchor.(type) {
+		case *ast.ParenExpr:
+			anchor = e.X
+			continue
+		case *ast.Ident:
+			if value, ok := consts[info.Uses[e]]; ok && fset.File(value.Pos()) == fset.File(expr.Pos()) {
+				anchor = value
+				continue
+			}
+		}
+		break
+	}
+
+	q := goQuery{sql: sql, line: fset.Position(anchor.Pos()).Line}
+	if lit, ok := anchor.(*ast.BasicLit); ok && strings.HasPrefix(lit.Value, "`") {
+		q.exact = true
+	}
+	return q, true
+}
+
+// newGoSQLFile создает запись SQL-файла для запроса из Go-кода. Source указывает строки Go-файла,
+// поэтому результаты ревью ссылаются на файл и строку литерала.
+func newGoSQLFile(path string, q goQuery) SQLFile {
+	lines := strings.Count(q.sql, "\n") + 1
+	source := make([]SourceLine, lines)
+	for i := range source {
+		line := q.line
+		if q.exact {
+			line += i
+		}
+		source[i] = SourceLine{Path: path, Line: line, Top: line}
+	}
+
+	return SQLFile{
+		Title:    fmt.Sprintf("%s:%d", filepath.Base(path), q.line),
+		Content:  q.sql,
+		Path:     path,
+		Source:   source,
+		Embedded: true,
+	}
+}
+
+// goIncludePatterns приспосабливает шаблоны --include к режиму Go, чтобы шаблоны, написанные
+// для SQL-файлов, не отсекали Go-исходники: шаблон файлов .sql (queries/*.sql) заменяется
+// его каталогом (queries/), а шаблон без каталога (*.sql) отбрасывается
+func goIncludePatterns(patterns []string) []string {
+	var out []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if !strings.HasSuffix(p, ".sql") {
+			out = append(out, p)
+			continue
+		}
+		dir := p[:strings.LastIndex(p, "/")+1]
+		if strings.Trim(strings.TrimPrefix(dir, "!"), "/") != "" {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+// inGoIgnoredDir сообщает, что файл лежит в каталоге, который go build не собирает:
+// vendor, testdata и каталоги, начинающиеся с . или _
+func inGoIgnoredDir(rel string) bool {
+	dirs := strings.Split(rel, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if dir == "vendor" || dir == "testdata" || strings.HasPrefix(dir, ".") || strings.HasPrefix(dir, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// stubImporter возвращает пустые пакеты вместо зависимостей: для вычисления констант
+// пакета зависимости не нужны, а их загрузка требует сборочного окружения
+type stubImporter struct{}
+
+func (stubImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+	return pkg, nil
+}
@@ -0,0 +1,94 @@
+package sqlfiles
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// goFixture каталог с Go-кодом для тестов режима GoFiles
+var goFixture = filepath.Join("testdata", "gosource")
+
+func TestCollectGoFiles(t *testing.T) {
+	files, err := CollectSQLFiles(SearchConfig{RootPath: goFixture, Mode: GoFiles})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := filepath.Join(goFixture, "users.go")
+	report := filepath.Join(goFixture, "reports", "report.go")
+	want := []struct {
+		title string
+		path  string
+		sql   string
+		lines []int
+	}{
+		// Каталоги обходятся в лексическом порядке
+		{"report.go:9", report, "SELECT day, sum(total) FROM orders GROUP BY day", []int{9}},
+		// Вычисленная конкатенация констант указывает на объявление константы
+		{"users.go:15", users, "SELECT id, email FROM users WHERE id = $1", []int{15}},
+		{"users.go:27", users, "SELECT id FROM users ORDER BY id", []int{27}},
+		// Строки raw-литерала совпадают со строками файла
+		{"users.go:31", users, "UPDATE users\nSET active = false\nWHERE id = $1", []int{31, 32, 33}},
+		{"users.go:38", users, "DELETE FROM sessions WHERE user_id = $1", []int{38}},
+		{"users.go:44", users, "SELECT count(*) FROM users", []int{44}},
+	}
+
+	if len(files) != len(want) {
+		for _, f := range files {
+			t.Logf("%s: %q", f.Title, f.Content)
+		}
+		t.Fatalf("got %d queries, want %d", len(files), len(want))
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.Title != w.title || f.Path != w.path || f.Content != w.sql || !f.Embedded {
+			t.Errorf("query %d = %s %s %q (embedded=%v), want %s %s %q", i, f.Title, f.Path, f.Content, f.Embedded, w.title, w.path, w.sql)
+			continue
+		}
+		var lines []int
+		for _, s := range f.Source {
+			if s.Path != w.path || s.Top != s.Line {
+				t.Errorf("%s: source line %+v", f.Title, s)
+			}
+			lines = append(lines, s.Line)
+		}
+		if !reflect.DeepEqual(lines, w.lines) {
+			t.Errorf("%s: source lines %v, want %v", f.Title, lines, w.lines)
+		}
+	}
+}
+
+func TestCollectGoFilesInclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		want    int
+	}{
+		{"no patterns", nil, 6},
+		{"sql file glob does not filter go files", []string{"*.sql"}, 6},
+		{"sql glob in a directory selects the directory", []string{"reports/*.sql"}, 1},
+		{"go file glob", []string{"users.go"}, 5},
+		{"directory", []string{"reports/"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := CollectSQLFiles(SearchConfig{RootPath: goFixture, Mode: GoFiles, Include: tt.include})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.want {
+				t.Errorf("got %d queries, want %d", len(files), tt.want)
+			}
+		})
+	}
+}
+
+func TestGoIncludePatterns(t *testing.T) {
+	got := goIncludePatterns([]string{"*.sql", "/*.sql", "queries/*.sql", "db/**/*.sql", "!legacy/*.sql", "internal/", "*.go"})
+	want := []string{"queries/", "db/**/", "!legacy/", "internal/", "*.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
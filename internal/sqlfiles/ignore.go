@@ -0,0 +1,236 @@
+package sqlfiles
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName файл в корне поиска с шаблонами исключаемых путей в формате .gitignore
+const IgnoreFileName = ".pgmonignore"
+
+// pathPattern шаблон пути в формате .gitignore
+type pathPattern struct {
+	re      *regexp.Regexp
+	negate  bool // !pattern возвращает ранее исключённый путь
+	dirOnly bool // pattern/ совпадает только с каталогами
+}
+
+// pathFilter отбирает файлы по .pgmonignore, списку игнорирования и шаблонам --include/--exclude.
+// Пути передаются относительно корня поиска через /.
+type pathFilter struct {
+	ignore  []pathPattern
+	include []pathPattern
+	exclude []pathPattern
+}
+
+// newPathFilter загружает .pgmonignore из корня поиска и разбирает шаблоны конфигурации
+func newPathFilter(config SearchConfig) (*pathFilter, error) {
+	f := &pathFilter{}
+
+	ignore, err := loadIgnoreFile(filepath.Join(config.RootPath, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	f.ignore = ignore
+
+	if config.EnableIgnoreList {
+		// Имя без / совпадает с файлом на любой глубине, как и раньше
+		patterns, err := parsePatterns(config.IgnoreFiles)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern: %w", err)
+		}
+		f.ignore = append(f.ignore, patterns...)
+	}
+	include := config.Include
+	if config.Mode == GoFiles {
+		include = goIncludePatterns(include)
+	}
+	if f.include, err = parsePatterns(include); err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	if f.exclude, err = parsePatterns(config.Exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return f, nil
+}
+
+// loadIgnoreFile читает шаблоны из файла; отсутствие файла не является ошибкой
+func loadIgnoreFile(path string) ([]pathPattern, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	patterns, err := parsePatterns(lines)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern in %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// parsePatterns разбирает строки шаблонов, пропуская пустые строки и комментарии #
+func parsePatterns(lines []string) ([]pathPattern, error) {
+	var patterns []pathPattern
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parsePattern(line)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// parsePattern разбирает шаблон .gitignore. Шаблон с / в начале или середине привязан к корню,
+// без / совпадает с именем на любой глубине; ** совпадает с любым числом каталогов.
+func parsePattern(line string) (pathPattern, error) {
+	p := pathPattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+
+	prefix := `^(?:.*/)?`
+	if strings.Contains(line, "/") {
+		prefix, line = "^", strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return p, fmt.Errorf("empty pattern")
+	}
+
+	re, err := regexp.Compile(prefix + globRegexp(line) + "$")
+	if err != nil {
+		return p, fmt.Errorf("%q: %w", line, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// globRegexp переводит glob с *, ?, [...] и ** в регулярное выражение
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				switch {
+				case atStart && i+2 < len(glob) && glob[i+2] == '/':
+					b.WriteString(`(?:.*/)?`)
+					i += 2
+					continue
+				case atStart && i+2 == len(glob):
+					b.WriteString(`.*`)
+					i++
+					continue
+				}
+				i++
+			}
+			b.WriteString(`[^/]*`)
+		case '?':
+			b.WriteString(`[^/]`)
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				c = glob[i]
+			}
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// matchPatterns применяет шаблоны по порядку: последний совпавший определяет результат
+func matchPatterns(patterns []pathPattern, rel string, isDir bool) bool {
+	matched := false
+	for _, p := range patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+// skipDir сообщает, что каталог исключён целиком и его не нужно обходить
+func (f *pathFilter) skipDir(rel string) bool {
+	if rel == "." || rel == "" {
+		return false
+	}
+	return matchPatterns(f.ignore, rel, true) || matchPatterns(f.exclude, rel, true)
+}
+
+// skipFile сообщает, что файл не нужно ревьюить. Каталоги пути проверяются так же,
+// как при обходе: исключённый каталог нельзя вернуть шаблоном ! для вложенного файла.
+func (f *pathFilter) skipFile(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if f.skipDir(strings.Join(parts[:i], "/")) {
+			return true
+		}
+	}
+	if matchPatterns(f.ignore, rel, false) || matchPatterns(f.exclude, rel, false) {
+		return true
+	}
+	return len(f.include) > 0 && !matchIncluded(f.include, rel)
+}
+
+// matchIncluded применяет шаблоны --include к файлу: шаблон совпадает с самим файлом или
+// с одним из его каталогов, поэтому queries/ выбирает все файлы каталога queries.
+// Последний совпавший шаблон определяет результат.
+func matchIncluded(patterns []pathPattern, rel string) bool {
+	parts := strings.Split(rel, "/")
+	matched := false
+	for _, p := range patterns {
+		hit := !p.dirOnly && p.re.MatchString(rel)
+		for i := 1; i < len(parts) && !hit; i++ {
+			hit = p.re.MatchString(strings.Join(parts[:i], "/"))
+		}
+		if hit {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
@@ -0,0 +1,137 @@
+package sqlfiles
+
+import (
+	"testing"
+)
+
+func TestParsePatternMatching(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		// Без / шаблон совпадает с именем на любой глубине
+		{"*.tmp.sql", "a.tmp.sql", false, true},
+		{"*.tmp.sql", "x/y/a.tmp.sql", false, true},
+		{"*.sql", "x/y.sql/z.go", false, false},
+		{"seed.sql", "db/seed.sql", false, true},
+		{"seed.sql", "db/seed.sql.bak", false, false},
+		// С / в начале или середине шаблон привязан к корню
+		{"/seed.sql", "seed.sql", false, true},
+		{"/seed.sql", "db/seed.sql", false, false},
+		{"db/seed.sql", "db/seed.sql", false, true},
+		{"db/seed.sql", "x/db/seed.sql", false, false},
+		// * и ? не переходят через /
+		{"db/*.sql", "db/a.sql", false, true},
+		{"db/*.sql", "db/x/a.sql", false, false},
+		{"db/?.sql", "db/a.sql", false, true},
+		{"db/?.sql", "db/ab.sql", false, false},
+		// **
+		{"**/fixtures", "fixtures", true, true},
+		{"**/fixtures", "a/b/fixtures", true, true},
+		{"db/**", "db/a/b.sql", false, true},
+		{"db/**", "dbx/a.sql", false, false},
+		{"a/**/b.sql", "a/b.sql", false, true},
+		{"a/**/b.sql", "a/x/y/b.sql", false, true},
+		{"a/**/b.sql", "ax/b.sql", false, false},
+		// Классы символов
+		{"v[0-9].sql", "v1.sql", false, true},
+		{"v[!0-9].sql", "v1.sql", false, false},
+		{"v[!0-9].sql", "vx.sql", false, true},
+		// Экранирование
+		{`\#hash.sql`, "#hash.sql", false, true},
+		{`a\*.sql`, "a*.sql", false, true},
+		{`a\*.sql`, "ab.sql", false, false},
+		// pattern/ совпадает только с каталогами
+		{"generated/", "generated", true, true},
+		{"generated/", "generated", false, false},
+		{"generated/", "x/generated", true, true},
+	}
+
+	for _, tt := range tests {
+		p, err := parsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("parsePattern(%q): %v", tt.pattern, err)
+		}
+		got := matchPatterns([]pathPattern{p}, tt.path, tt.isDir)
+		if got != tt.want {
+			t.Errorf("pattern %q on %q (dir=%v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	patterns, err := parsePatterns([]string{"# comment", "", "  ", "*.sql  ", "!keep.sql", `\!bang.sql`, "tmp/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 4 {
+		t.Fatalf("got %d patterns, want 4", len(patterns))
+	}
+	if !patterns[1].negate || patterns[2].negate {
+		t.Errorf("negation: got %v and %v, want true and false", patterns[1].negate, patterns[2].negate)
+	}
+	if !patterns[3].dirOnly {
+		t.Error("tmp/ should match directories only")
+	}
+
+	// Последний совпавший шаблон определяет результат
+	if matchPatterns(patterns, "db/keep.sql", false) {
+		t.Error("keep.sql should be re-included by the negated pattern")
+	}
+	if !matchPatterns(patterns, "db/other.sql", false) {
+		t.Error("other.sql should be matched by *.sql")
+	}
+	if !matchPatterns(patterns, "!bang.sql", false) {
+		t.Error(`\!bang.sql should match a file starting with !`)
+	}
+
+	if _, err := parsePatterns([]string{"/"}); err == nil {
+		t.Error("expected an error for an empty pattern")
+	}
+}
+
+func TestPathFilterSkipFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		ignore  []string
+		include []string
+		exclude []string
+		path    string
+		skip    bool
+	}{
+		{name: "no patterns", path: "db/a.sql", skip: false},
+		{name: "excluded directory", exclude: []string{"generated/"}, path: "generated/x/a.sql", skip: true},
+		{name: "excluded directory cannot be re-included", ignore: []string{"tmp/", "!tmp/keep.sql"}, path: "tmp/keep.sql", skip: true},
+		{name: "re-included file", ignore: []string{"*.sql", "!keep.sql"}, path: "db/keep.sql", skip: false},
+		{name: "include file glob", include: []string{"*.sql"}, path: "db/a.sql", skip: false},
+		{name: "include file glob misses", include: []string{"queries/*.sql"}, path: "db/a.sql", skip: true},
+		{name: "include directory", include: []string{"queries/"}, path: "queries/a.sql", skip: false},
+		{name: "include directory nested file", include: []string{"queries/"}, path: "queries/reports/a.sql", skip: false},
+		{name: "include directory at any depth", include: []string{"queries/"}, path: "app/queries/a.sql", skip: false},
+		{name: "include directory misses", include: []string{"queries/"}, path: "migrations/a.sql", skip: true},
+		{name: "include directory does not match files", include: []string{"queries/"}, path: "queries", skip: true},
+		{name: "include directory with negation", include: []string{"queries/", "!queries/legacy/"}, path: "queries/legacy/a.sql", skip: true},
+		{name: "exclude wins over include", include: []string{"queries/"}, exclude: []string{"*_test.sql"}, path: "queries/a_test.sql", skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f pathFilter
+			var err error
+			if f.ignore, err = parsePatterns(tt.ignore); err != nil {
+				t.Fatal(err)
+			}
+			if f.include, err = parsePatterns(tt.include); err != nil {
+				t.Fatal(err)
+			}
+			if f.exclude, err = parsePatterns(tt.exclude); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.skipFile(tt.path); got != tt.skip {
+				t.Errorf("skipFile(%q) = %v, want %v", tt.path, got, tt.skip)
+			}
+		})
+	}
+}
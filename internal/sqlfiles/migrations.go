@@ -0,0 +1,376 @@
+package sqlfiles
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MigrationTool инструмент миграций, по соглашениям которого распознан файл
+type MigrationTool string
+
+const (
+	ToolUnknown       MigrationTool = ""
+	ToolGolangMigrate MigrationTool = "golang-migrate"
+	ToolGoose         MigrationTool = "goose"
+	ToolFlyway        MigrationTool = "flyway"
+	ToolSqitch        MigrationTool = "sqitch"
+	ToolLiquibase     MigrationTool = "liquibase"
+)
+
+// Direction направление миграции
+type Direction string
+
+const (
+	DirectionUp         Direction = "up"
+	DirectionDown       Direction = "down"
+	DirectionRepeatable Direction = "repeatable"
+)
+
+// Migration метаданные миграции. Для пары up/down файл SQLFile содержит up-часть,
+// а откат хранится в Down, чтобы ревью видело обе части вместе.
+// Transactional означает, что инструмент сам выполняет миграцию в транзакции.
+type Migration struct {
+	Tool          MigrationTool `json:"tool,omitempty"`
+	Version       string        `json:"version,omitempty"`
+	Direction     Direction     `json:"direction"`
+	Description   string        `json:"description,omitempty"`
+	Transactional bool          `json:"transactional,omitempty"`
+	Down          string        `json:"-"`
+	DownPath      string        `json:"down_path,omitempty"`
+}
+
+// MigrationIssue проблема в наборе миграций: дубли версий, откат без миграции и т.п.
+type MigrationIssue struct {
+	Path    string
+	Message string
+}
+
+var (
+	golangMigrateName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	gooseName         = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+	flywayName        = regexp.MustCompile(`^([VU])(\d+(?:[._]\d+)*)__(.+)\.sql$`)
+	flywayRepeatable  = regexp.MustCompile(`^R__(.+)\.sql$`)
+	gooseAnnotation   = regexp.MustCompile(`(?m)^\s*--\s*\+goose\s+(Up|Down)\b`)
+	liquibaseHeader   = regexp.MustCompile(`(?i)^\s*--\s*liquibase formatted sql`)
+	liquibaseChange   = regexp.MustCompile(`(?m)^\s*--\s*changeset\s+[^:\s]+:(\S+)`)
+	liquibaseRollback = regexp.MustCompile(`(?m)^\s*--\s*rollback\s+(.*)$`)
+
+	gooseNoTransaction     = regexp.MustCompile(`(?m)^\s*--\s*\+goose\s+NO\s+TRANSACTION\b`)
+	flywayNoTransaction    = regexp.MustCompile(`(?m)^\s*(?:--\s*flyway:)?executeInTransaction\s*=\s*false\b`)
+	liquibaseNoTransaction = regexp.MustCompile(`(?m)^\s*--\s*changeset\s.*\brunInTransaction:false\b`)
+)
+
+// migrationFile файл миграции до объединения пар up/down
+type migrationFile struct {
+	SQLFile
+	Migration
+	key string // Ключ пары up/down
+}
+
+// DetectMigration распознаёт соглашения инструмента миграций по имени и содержимому файла.
+// Для sqitch нужен план, поэтому такие файлы распознаются только при сборе каталога.
+// goose, flyway и liquibase выполняют миграцию в транзакции, если она не отключена
+// аннотацией в файле; golang-migrate и sqitch транзакцию не открывают.
+func DetectMigration(name, content string) Migration {
+	if liquibaseHeader.MatchString(content) {
+		m := Migration{Tool: ToolLiquibase, Direction: DirectionUp}
+		var ids, rollbacks []string
+		for _, c := range liquibaseChange.FindAllStringSubmatch(content, -1) {
+			ids = append(ids, c[1])
+		}
+		for _, r := range liquibaseRollback.FindAllStringSubmatch(content, -1) {
+			rollbacks = append(rollbacks, r[1])
+		}
+		if v := gooseName.FindStringSubmatch(name); v != nil {
+			m.Version = v[1]
+		}
+		m.Description = strings.Join(ids, ", ")
+		m.Down = strings.Join(rollbacks, "\n")
+		m.Transactional = !liquibaseNoTransaction.MatchString(content)
+		return m
+	}
+
+	if gooseAnnotation.MatchString(content) {
+		m := Migration{Tool: ToolGoose, Direction: DirectionUp, Transactional: !gooseNoTransaction.MatchString(content)}
+		if v := gooseName.FindStringSubmatch(name); v != nil {
+			m.Version, m.Description = v[1], describe(v[2])
+		}
+		return m
+	}
+
+	if v := golangMigrateName.FindStringSubmatch(name); v != nil {
+		return Migration{Tool: ToolGolangMigrate, Version: v[1], Direction: Direction(v[3]), Description: describe(v[2])}
+	}
+
+	if v := flywayName.FindStringSubmatch(name); v != nil {
+		direction := DirectionUp
+		if v[1] == "U" {
+			direction = DirectionDown
+		}
+		return Migration{Tool: ToolFlyway, Version: v[2], Direction: direction, Description: describe(v[3]),
+			Transactional: !flywayNoTransaction.MatchString(content)}
+	}
+	if v := flywayRepeatable.FindStringSubmatch(name); v != nil {
+		return Migration{Tool: ToolFlyway, Direction: DirectionRepeatable, Description: describe(v[1]),
+			Transactional: !flywayNoTransaction.MatchString(content)}
+	}
+
+	return Migration{Direction: DirectionUp}
+}
+
+// flywayScriptConfig проверяет конфигурацию скрипта flyway (V1__x.sql.conf) на
+// executeInTransaction=false
+func flywayScriptConfig(path string) (transactional bool) {
+	content, err := os.ReadFile(path + ".conf")
+	if err != nil {
+		return true
+	}
+	return !flywayNoTransaction.Match(content)
+}
+
+// splitGoose делит файл goose на Up- и Down-части. Строки Down-части в up заменяются
+// пустыми, чтобы номера строк совпадали с исходным файлом.
+func splitGoose(content string) (up, down string) {
+	lines := strings.Split(content, "\n")
+	var upLines, downLines []string
+	inDown := false
+	for _, line := range lines {
+		if m := gooseAnnotation.FindStringSubmatch(line); m != nil {
+			inDown = m[1] == "Down"
+		}
+		if inDown {
+			upLines = append(upLines, "")
+			downLines = append(downLines, line)
+		} else {
+			upLines = append(upLines, line)
+		}
+	}
+	return strings.Join(upLines, "\n"), strings.Join(downLines, "\n")
+}
+
+// describe превращает часть имени файла в описание: add_users_email -> add users email
+func describe(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "_", " "))
+}
+
+// sqitchPlan возвращает порядок изменений из sqitch.plan или nil, если плана нет
+func sqitchPlan(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, "sqitch.plan"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqitch plan: %w", err)
+	}
+	defer f.Close()
+
+	var changes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		changes = append(changes, strings.Fields(line)[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sqitch plan: %w", err)
+	}
+	return changes, nil
+}
+
+// detectSqitch распознаёт файл каталога deploy/ или revert/ проекта sqitch
+func detectSqitch(root, path string, plan []string) (Migration, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return Migration{}, false
+	}
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+	if len(parts) != 2 {
+		return Migration{}, false
+	}
+
+	change := strings.TrimSuffix(parts[1], ".sql")
+	m := Migration{Tool: ToolSqitch, Description: change}
+	switch parts[0] {
+	case "deploy":
+		m.Direction = DirectionUp
+	case "revert":
+		m.Direction = DirectionDown
+	case "verify":
+		// verify-скрипты не изменяют схему и не ревьюятся
+		return Migration{}, true
+	default:
+		return Migration{}, false
+	}
+
+	for i, c := range plan {
+		if c == change {
+			m.Version = strconv.Itoa(i + 1)
+		}
+	}
+	return m, true
+}
+
+// orderMigrations объединяет пары up/down и упорядочивает миграции: версионные по номеру
+// версии, затем нераспознанные по пути, затем повторяемые по описанию.
+// Откат без пары остаётся отдельной миграцией, о нём сообщает CheckMigrations.
+func orderMigrations(files []migrationFile) []SQLFile {
+	ups := make(map[string]*migrationFile)
+	var downs []migrationFile
+	var ordered []*migrationFile
+
+	for i := range files {
+		f := &files[i]
+		if f.Direction == DirectionDown {
+			downs = append(downs, *f)
+			continue
+		}
+		if _, ok := ups[f.key]; f.key != "" && !ok {
+			ups[f.key] = f
+		}
+		ordered = append(ordered, f)
+	}
+
+	for i := range downs {
+		if up, ok := ups[downs[i].key]; ok && up.DownPath == "" {
+			up.Down, up.DownPath = downs[i].Content, downs[i].Path
+			continue
+		}
+		ordered = append(ordered, &downs[i])
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if ra, rb := migrationRank(a.Migration), migrationRank(b.Migration); ra != rb {
+			return ra < rb
+		}
+		switch migrationRank(a.Migration) {
+		case 0:
+			return CompareVersions(a.Version, b.Version) < 0
+		case 2:
+			return a.Description < b.Description
+		default:
+			return a.Path < b.Path
+		}
+	})
+
+	out := make([]SQLFile, 0, len(ordered))
+	for _, f := range ordered {
+		m := f.Migration
+		file := f.SQLFile
+		file.Migration = &m
+		out = append(out, file)
+	}
+	return out
+}
+
+// CheckMigrations проверяет набор упорядоченных миграций: дубли версий, откаты без миграции,
+// миграции golang-migrate без отката и смесь последовательных и временных номеров версий
+func CheckMigrations(files []SQLFile) []MigrationIssue {
+	var issues []MigrationIssue
+	seen := make(map[string]string)
+	var versioned []SQLFile
+
+	for _, f := range files {
+		m := f.Migration
+		if m == nil || m.Version == "" {
+			continue
+		}
+		versioned = append(versioned, f)
+
+		if m.Direction == DirectionDown {
+			issues = append(issues, MigrationIssue{Path: f.Path, Message: fmt.Sprintf("%s down migration %s has no matching up migration", m.Tool, m.Version)})
+			continue
+		}
+		key := string(m.Tool) + ":" + m.Version
+		if prev, ok := seen[key]; ok {
+			issues = append(issues, MigrationIssue{Path: f.Path, Message: fmt.Sprintf("duplicate %s migration version %s (also %s)", m.Tool, m.Version, prev)})
+		} else {
+			seen[key] = f.Path
+		}
+		if m.Tool == ToolGolangMigrate && m.DownPath == "" {
+			issues = append(issues, MigrationIssue{Path: f.Path, Message: fmt.Sprintf("migration %s has no down migration", m.Version)})
+		}
+	}
+
+	return append(issues, versionSchemeIssues(versioned)...)
+}
+
+// migrationRank группа для сортировки: версионные, нераспознанные, повторяемые
+func migrationRank(f Migration) int {
+	switch {
+	case f.Direction == DirectionRepeatable:
+		return 2
+	case f.Version == "":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// versionSchemeIssues сообщает о смеси последовательных (001) и временных (20240101120000)
+// номеров версий одного инструмента: миграции применятся не в порядке создания
+func versionSchemeIssues(files []SQLFile) []MigrationIssue {
+	const timestampDigits = 12
+
+	sequential := make(map[MigrationTool]string)
+	timestamp := make(map[MigrationTool]string)
+	for _, f := range files {
+		m := f.Migration
+		if m.Tool == ToolSqitch {
+			continue
+		}
+		digits := strings.SplitN(strings.NewReplacer("_", ".").Replace(m.Version), ".", 2)[0]
+		if len(digits) >= timestampDigits {
+			timestamp[m.Tool] = f.Path
+		} else {
+			sequential[m.Tool] = f.Path
+		}
+	}
+
+	// Инструменты перебираются в отсортированном порядке, чтобы предупреждения не менялись между запусками
+	tools := make([]string, 0, len(timestamp))
+	for tool := range timestamp {
+		tools = append(tools, string(tool))
+	}
+	sort.Strings(tools)
+
+	var issues []MigrationIssue
+	for _, tool := range tools {
+		if seq, ok := sequential[MigrationTool(tool)]; ok {
+			issues = append(issues, MigrationIssue{
+				Path:    timestamp[MigrationTool(tool)],
+				Message: fmt.Sprintf("%s migrations mix sequential (%s) and timestamp versions, they will run out of order", tool, filepath.Base(seq)),
+			})
+		}
+	}
+	return issues
+}
+
+// CompareVersions сравнивает версии вида 1.2.10 или 20240101 по числовым частям: <0, 0 или >0
+func CompareVersions(a, b string) int {
+	pa := strings.FieldsFunc(a, func(r rune) bool { return r == '.' || r == '_' })
+	pb := strings.FieldsFunc(b, func(r rune) bool { return r == '.' || r == '_' })
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.ParseUint(pa[i], 10, 64)
+		nb, errB := strconv.ParseUint(pb[i], 10, 64)
+		switch {
+		case errA != nil || errB != nil:
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
@@ -0,0 +1,253 @@
+package sqlfiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectMigration(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    Migration
+	}{
+		{
+			name: "golang-migrate up",
+			file: "000001_add_users.up.sql",
+			want: Migration{Tool: ToolGolangMigrate, Version: "000001", Direction: DirectionUp, Description: "add users"},
+		},
+		{
+			name: "golang-migrate down",
+			file: "000001_add_users.down.sql",
+			want: Migration{Tool: ToolGolangMigrate, Version: "000001", Direction: DirectionDown, Description: "add users"},
+		},
+		{
+			name:    "goose",
+			file:    "20240101120000_add_email.sql",
+			content: "-- +goose Up\nalter table users add email text;\n-- +goose Down\nalter table users drop email;",
+			want:    Migration{Tool: ToolGoose, Version: "20240101120000", Direction: DirectionUp, Description: "add email", Transactional: true},
+		},
+		{
+			name:    "goose without transaction",
+			file:    "00002_index.sql",
+			content: "-- +goose NO TRANSACTION\n-- +goose Up\ncreate index concurrently i on t (a);",
+			want:    Migration{Tool: ToolGoose, Version: "00002", Direction: DirectionUp, Description: "index"},
+		},
+		{
+			name: "flyway versioned",
+			file: "V1_2__create_orders.sql",
+			want: Migration{Tool: ToolFlyway, Version: "1_2", Direction: DirectionUp, Description: "create orders", Transactional: true},
+		},
+		{
+			name: "flyway undo",
+			file: "U3__create_orders.sql",
+			want: Migration{Tool: ToolFlyway, Version: "3", Direction: DirectionDown, Description: "create orders", Transactional: true},
+		},
+		{
+			name:    "flyway repeatable without transaction",
+			file:    "R__refresh_views.sql",
+			content: "-- flyway:executeInTransaction=false\nrefresh materialized view concurrently v;",
+			want:    Migration{Tool: ToolFlyway, Direction: DirectionRepeatable, Description: "refresh views"},
+		},
+		{
+			name:    "liquibase",
+			file:    "003_changelog.sql",
+			content: "--liquibase formatted sql\n--changeset alice:create-t\ncreate table t (a int);\n--rollback drop table t;\n--changeset bob:idx runInTransaction:false\ncreate index concurrently i on t (a);\n--rollback drop index i;",
+			want: Migration{Tool: ToolLiquibase, Version: "003", Direction: DirectionUp, Description: "create-t, idx",
+				Down: "drop table t;\ndrop index i;"},
+		},
+		{
+			name: "unknown",
+			file: "cleanup.sql",
+			want: Migration{Direction: DirectionUp},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectMigration(tt.file, tt.content); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitGoose(t *testing.T) {
+	content := "-- +goose Up\ncreate table t (a int);\n\n-- +goose Down\ndrop table t;"
+	up, down := splitGoose(content)
+	if want := "-- +goose Up\ncreate table t (a int);\n\n\n"; up != want {
+		t.Errorf("up = %q, want %q", up, want)
+	}
+	if want := "-- +goose Down\ndrop table t;"; down != want {
+		t.Errorf("down = %q, want %q", down, want)
+	}
+	// Номера строк up-части совпадают с исходным файлом
+	if strings.Count(up, "\n") != strings.Count(content, "\n") {
+		t.Errorf("up part has %d lines, want %d", strings.Count(up, "\n")+1, strings.Count(content, "\n")+1)
+	}
+}
+
+// writeFiles создаёт файлы в dir и возвращает dir
+func writeFiles(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCollectMigrationsOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string // Заголовок миграции и, через ":", имя файла отката
+	}{
+		{
+			name: "golang-migrate pairs by numeric version",
+			files: map[string]string{
+				"10_c.up.sql": "", "10_c.down.sql": "",
+				"2_b.up.sql": "", "2_b.down.sql": "",
+				"1_a.up.sql":   "",
+				"3_x.down.sql": "",
+			},
+			// Откат без пары остаётся на месте своей версии
+			want: []string{"1_a.up.sql", "2_b.up.sql:2_b.down.sql", "3_x.down.sql", "10_c.up.sql:10_c.down.sql"},
+		},
+		{
+			name: "flyway versioned, unknown and repeatable",
+			files: map[string]string{
+				"R__views.sql":     "",
+				"V1_10__c.sql":     "",
+				"V1_2__b.sql":      "",
+				"U1_2__b.sql":      "",
+				"notes.sql":        "",
+				"R__functions.sql": "",
+			},
+			want: []string{"V1_2__b.sql:U1_2__b.sql", "V1_10__c.sql", "notes.sql", "R__functions.sql", "R__views.sql"},
+		},
+		{
+			name: "sqitch plan order",
+			files: map[string]string{
+				"sqitch.plan":       "%project=app\n\nusers 2024-01-01 a <a@x>\norders [users] 2024-01-02 a <a@x>\n",
+				"deploy/orders.sql": "",
+				"deploy/users.sql":  "",
+				"revert/users.sql":  "",
+				"verify/users.sql":  "",
+			},
+			want: []string{"users.sql:users.sql", "orders.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, t.TempDir(), tt.files)
+			files, err := CollectSQLFiles(SearchConfig{RootPath: dir, Mode: MigrationsOnly, MigrationsPath: dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range files {
+				name := filepath.Base(f.Path)
+				if f.Migration.DownPath != "" {
+					name += ":" + filepath.Base(f.Migration.DownPath)
+				}
+				got = append(got, name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectMigrationsGooseAndFlywayConfig(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"00001_users.sql":         "-- +goose Up\ncreate table users (id int);\n-- +goose Down\ndrop table users;\n",
+		"V2__index.sql":           "create index concurrently i on users (id);",
+		"V2__index.sql.conf":      "executeInTransaction=false\n",
+		"V3__column.sql":          "alter table users add email text;",
+		"V4__view.sql.conf.extra": "",
+	})
+	files, err := CollectSQLFiles(SearchConfig{RootPath: dir, Mode: MigrationsOnly, MigrationsPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d migrations, want 3", len(files))
+	}
+
+	goose := files[0]
+	if goose.Content != "-- +goose Up\ncreate table users (id int);\n\n\n" || goose.Migration.Down != "-- +goose Down\ndrop table users;\n" {
+		t.Errorf("goose up %q, down %q", goose.Content, goose.Migration.Down)
+	}
+	if !goose.Migration.Transactional {
+		t.Error("goose migration should run in a transaction")
+	}
+	if files[1].Migration.Transactional {
+		t.Error("executeInTransaction=false in the script config was ignored")
+	}
+	if !files[2].Migration.Transactional {
+		t.Error("flyway migration without a config should run in a transaction")
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	migration := func(path string, m Migration) SQLFile {
+		return SQLFile{Path: path, Migration: &m}
+	}
+	files := []SQLFile{
+		migration("1_a.up.sql", Migration{Tool: ToolGolangMigrate, Version: "1", Direction: DirectionUp, DownPath: "1_a.down.sql"}),
+		migration("1_b.up.sql", Migration{Tool: ToolGolangMigrate, Version: "1", Direction: DirectionUp, DownPath: "1_b.down.sql"}),
+		migration("20240101120000_c.up.sql", Migration{Tool: ToolGolangMigrate, Version: "20240101120000", Direction: DirectionUp}),
+		migration("7_x.down.sql", Migration{Tool: ToolGolangMigrate, Version: "7", Direction: DirectionDown}),
+		migration("V1__a.sql", Migration{Tool: ToolFlyway, Version: "1", Direction: DirectionUp}),
+		migration("V202401011200__b.sql", Migration{Tool: ToolFlyway, Version: "202401011200", Direction: DirectionUp}),
+		migration("00001_a.sql", Migration{Tool: ToolGoose, Version: "00001", Direction: DirectionUp}),
+		migration("R__v.sql", Migration{Tool: ToolFlyway, Direction: DirectionRepeatable}),
+		{Path: "plain.sql"},
+	}
+
+	want := []MigrationIssue{
+		{Path: "1_b.up.sql", Message: "duplicate golang-migrate migration version 1 (also 1_a.up.sql)"},
+		{Path: "20240101120000_c.up.sql", Message: "migration 20240101120000 has no down migration"},
+		{Path: "7_x.down.sql", Message: "golang-migrate down migration 7 has no matching up migration"},
+		// Предупреждения о смеси версий идут в порядке инструментов, а не обхода map
+		{Path: "V202401011200__b.sql", Message: "flyway migrations mix sequential (V1__a.sql) and timestamp versions, they will run out of order"},
+		{Path: "20240101120000_c.up.sql", Message: "golang-migrate migrations mix sequential (7_x.down.sql) and timestamp versions, they will run out of order"},
+	}
+	for i := 0; i < 20; i++ {
+		if got := CheckMigrations(files); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d:\n got %+v\nwant %+v", i, got, want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1", 0},
+		{"2", "10", -1},
+		{"1.10", "1.2", 1},
+		{"1_2", "1.2", 0},
+		{"1.2", "1.2.1", -1},
+		{"0002", "10", -1},
+	}
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("CompareVersions(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
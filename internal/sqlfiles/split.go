@@ -0,0 +1,338 @@
+package sqlfiles
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StatementKind тип оператора в SQL-файле
+type StatementKind int
+
+const (
+	KindSQL         StatementKind = iota // Обычный SQL-оператор
+	KindMetaCommand                      // Мета-команда psql (\set, \i, \connect ...)
+)
+
+// Statement оператор SQL-файла с положением в исходном тексте.
+// Offset и End — байтовые смещения [Offset, End) без ведущих пробелов и комментариев,
+// Line и EndLine — номера строк с 1.
+type Statement struct {
+	SQL     string
+	Kind    StatementKind
+	Offset  int
+	End     int
+	Line    int
+	EndLine int
+	// CopyData данные COPY ... FROM stdin, следующие за оператором (без завершающей строки \.)
+	CopyData string
+}
+
+// copyFromStdinPattern оператор COPY, за которым в файле следуют данные
+var copyFromStdinPattern = regexp.MustCompile(`(?is)^\s*copy\b.*\bfrom\s+stdin\b`)
+
+// SplitStatements разбивает содержимое SQL-файла на операторы с учётом синтаксиса PostgreSQL:
+// строк в одинарных кавычках (включая E” с экранированием обратным слешем), идентификаторов
+// в двойных кавычках, dollar-quoted тел ($$ и $tag$), тел BEGIN ATOMIC ... END, строчных и вложенных
+// блочных комментариев, данных COPY ... FROM stdin до строки \. и мета-команд psql: как и в psql,
+// обратный слеш вне строк и комментариев завершает текущий оператор, а мета-команда занимает
+// остаток строки.
+// Пустые операторы и файлы из одних комментариев дают пустой результат.
+func SplitStatements(content string) []Statement {
+	s := splitter{src: content, line: 1}
+	return s.split()
+}
+
+type splitter struct {
+	src   string
+	pos   int
+	line  int
+	stmts []Statement
+}
+
+func (s *splitter) split() []Statement {
+	for {
+		s.skipSpaceAndComments()
+		if s.pos >= len(s.src) {
+			return s.stmts
+		}
+
+		if s.src[s.pos] == '\\' {
+			s.metaCommand()
+			continue
+		}
+		s.statement()
+	}
+}
+
+// statement читает оператор до точки с запятой вне строк, комментариев и тел BEGIN ATOMIC
+func (s *splitter) statement() {
+	start, startLine := s.pos, s.line
+	end, endLine := start, startLine
+	// depth глубина вложенности BEGIN ATOMIC ... END; внутри тела CASE ... END тоже
+	// заканчивается на END и учитывается, как в psql
+	depth := 0
+	prevWord := ""
+
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == ';' && depth > 0:
+			s.advance()
+
+		case c == ';':
+			s.pos++
+			end, endLine = s.pos, s.line
+			s.emit(start, end, startLine, endLine)
+			return
+
+		case c == '\'':
+			escapes := s.pos > start && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E') &&
+				(s.pos-1 == start || !isIdentByte(s.src[s.pos-2]))
+			s.skipQuoted('\'', escapes)
+
+		case c == '"':
+			s.skipQuoted('"', false)
+
+		case c == '$':
+			if tag := s.dollarTagAt(s.pos); tag != "" {
+				s.skipDollarQuoted(tag)
+			} else {
+				s.advance()
+			}
+
+		case c == '-' && strings.HasPrefix(s.src[s.pos:], "--"):
+			// Комментарии в конце оператора в его границы не входят
+			s.skipLineComment()
+			continue
+
+		case c == '/' && strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.skipBlockComment()
+			continue
+
+		case c == '\\':
+			// Мета-команда psql (\g, \gset, \gexec ...) завершает оператор, как в psql
+			s.emit(start, end, startLine, endLine)
+			return
+
+		case isIdentByte(c) && (s.pos == 0 || !isIdentByte(s.src[s.pos-1])):
+			word := s.word()
+			switch {
+			case word == "atomic" && prevWord == "begin":
+				depth++
+			case word == "case" && depth > 0:
+				depth++
+			case word == "end" && depth > 0:
+				depth--
+			}
+			prevWord = word
+
+		default:
+			if !isSpaceByte(c) {
+				prevWord = ""
+			}
+			s.advance()
+		}
+
+		if !isSpaceByte(c) {
+			end, endLine = s.pos, s.line
+		}
+	}
+
+	s.emit(start, end, startLine, endLine)
+}
+
+// emit добавляет оператор; для COPY ... FROM stdin считывает следующие за ним данные
+func (s *splitter) emit(start, end, line, endLine int) {
+	sql := strings.TrimSpace(s.src[start:end])
+	if sql == "" || sql == ";" {
+		return
+	}
+
+	stmt := Statement{SQL: sql, Kind: KindSQL, Offset: start, End: end, Line: line, EndLine: endLine}
+	if copyFromStdinPattern.MatchString(sql) {
+		stmt.CopyData = s.copyData()
+	}
+	s.stmts = append(s.stmts, stmt)
+}
+
+// copyData читает строки данных COPY до строки \. или конца файла
+func (s *splitter) copyData() string {
+	// Остаток строки после точки с запятой к данным не относится
+	s.skipRestOfLine()
+
+	start := s.pos
+	for s.pos < len(s.src) {
+		lineEnd := strings.IndexByte(s.src[s.pos:], '\n')
+		var line string
+		if lineEnd < 0 {
+			line = s.src[s.pos:]
+		} else {
+			line = s.src[s.pos : s.pos+lineEnd]
+		}
+
+		if strings.TrimRight(line, "\r") == `\.` {
+			data := s.src[start:s.pos]
+			s.pos += len(line)
+			if lineEnd >= 0 {
+				s.pos++
+				s.line++
+			}
+			return data
+		}
+
+		s.pos += len(line)
+		if lineEnd >= 0 {
+			s.pos++
+			s.line++
+		}
+	}
+	return s.src[start:]
+}
+
+// metaCommand читает мета-команду psql до конца строки
+func (s *splitter) metaCommand() {
+	start, line := s.pos, s.line
+	end := strings.IndexByte(s.src[s.pos:], '\n')
+	if end < 0 {
+		end = len(s.src)
+	} else {
+		end += s.pos
+	}
+
+	s.pos = end
+	s.stmts = append(s.stmts, Statement{
+		SQL:     strings.TrimSpace(s.src[start:end]),
+		Kind:    KindMetaCommand,
+		Offset:  start,
+		End:     start + len(strings.TrimRight(s.src[start:end], " \t\r")),
+		Line:    line,
+		EndLine: line,
+	})
+}
+
+func (s *splitter) skipSpaceAndComments() {
+	for s.pos < len(s.src) {
+		switch {
+		case isSpaceByte(s.src[s.pos]):
+			s.advance()
+		case strings.HasPrefix(s.src[s.pos:], "--"):
+			s.skipLineComment()
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.skipBlockComment()
+		case s.src[s.pos] == ';':
+			// Пустой оператор
+			s.advance()
+		default:
+			return
+		}
+	}
+}
+
+func (s *splitter) skipLineComment() {
+	for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+func (s *splitter) skipRestOfLine() {
+	s.skipLineComment()
+	if s.pos < len(s.src) {
+		s.advance()
+	}
+}
+
+// skipBlockComment пропускает блочный комментарий с учётом вложенности
+func (s *splitter) skipBlockComment() {
+	depth := 0
+	for s.pos < len(s.src) {
+		switch {
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			depth++
+			s.pos += 2
+		case strings.HasPrefix(s.src[s.pos:], "*/"):
+			depth--
+			s.pos += 2
+			if depth == 0 {
+				return
+			}
+		default:
+			s.advance()
+		}
+	}
+}
+
+// skipQuoted пропускает строку или идентификатор в кавычках; удвоенная кавычка экранирует
+func (s *splitter) skipQuoted(quote byte, backslash bool) {
+	s.pos++
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case backslash && c == '\\':
+			s.advance()
+			if s.pos < len(s.src) {
+				s.advance()
+			}
+		case c == quote && s.pos+1 < len(s.src) && s.src[s.pos+1] == quote:
+			s.pos += 2
+		case c == quote:
+			s.pos++
+			return
+		default:
+			s.advance()
+		}
+	}
+}
+
+// dollarTagAt возвращает тег dollar-quoted строки ($$ или $tag$), начинающийся в позиции i.
+// $1 и идентификаторы вида a$b тегом не считаются.
+func (s *splitter) dollarTagAt(i int) string {
+	if i > 0 && isIdentByte(s.src[i-1]) {
+		return ""
+	}
+	for j := i + 1; j < len(s.src); j++ {
+		c := s.src[j]
+		if c == '$' {
+			return s.src[i : j+1]
+		}
+		if !isIdentByte(c) || (j == i+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func (s *splitter) skipDollarQuoted(tag string) {
+	s.pos += len(tag)
+	end := strings.Index(s.src[s.pos:], tag)
+	if end < 0 {
+		end = len(s.src) - s.pos
+	} else {
+		end += len(tag)
+	}
+	s.line += strings.Count(s.src[s.pos:s.pos+end], "\n")
+	s.pos += end
+}
+
+func (s *splitter) advance() {
+	if s.src[s.pos] == '\n' {
+		s.line++
+	}
+	s.pos++
+}
+
+// word читает слово из байтов идентификатора и возвращает его в нижнем регистре
+func (s *splitter) word() string {
+	start := s.pos
+	for s.pos < len(s.src) && isIdentByte(s.src[s.pos]) {
+		s.pos++
+	}
+	return strings.ToLower(s.src[start:s.pos])
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
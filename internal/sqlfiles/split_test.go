@@ -0,0 +1,224 @@
+package sqlfiles
+
+import (
+	"testing"
+)
+
+type wantStatement struct {
+	sql      string
+	kind     StatementKind
+	line     int
+	endLine  int
+	copyData string
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []wantStatement
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    nil,
+		},
+		{
+			name:    "comments and empty statements only",
+			content: "-- header\n/* block */\n;\n ; \n",
+			want:    nil,
+		},
+		{
+			name:    "simple statements with line numbers",
+			content: "select 1;\n\nselect\n  2;\nselect 3",
+			want: []wantStatement{
+				{sql: "select 1;", line: 1, endLine: 1},
+				{sql: "select\n  2;", line: 3, endLine: 4},
+				{sql: "select 3", line: 5, endLine: 5},
+			},
+		},
+		{
+			name:    "leading comments are not part of the statement",
+			content: "-- first\n/* second */\nselect 1;",
+			want:    []wantStatement{{sql: "select 1;", line: 3, endLine: 3}},
+		},
+		{
+			name:    "trailing line comment is not part of the statement",
+			content: "select 1 -- tail\n;",
+			want:    []wantStatement{{sql: "select 1 -- tail\n;", line: 1, endLine: 2}},
+		},
+		{
+			name:    "semicolon in single quoted string",
+			content: "select 'a;b', 'it''s;';\nselect 2;",
+			want: []wantStatement{
+				{sql: "select 'a;b', 'it''s;';", line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "escape string with backslash quote",
+			content: `select E'a\';b';` + "\nselect 2;",
+			want: []wantStatement{
+				{sql: `select E'a\';b';`, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "backslash in standard string does not escape",
+			content: `select 'a\';` + "\nselect 2;",
+			want: []wantStatement{
+				{sql: `select 'a\';`, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "identifier ending in e before a string is not an escape string",
+			content: `select name'x\';` + "\nselect 2;",
+			want: []wantStatement{
+				{sql: `select name'x\';`, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "semicolon in quoted identifier",
+			content: `select 1 as "a;""b";` + "\nselect 2;",
+			want: []wantStatement{
+				{sql: `select 1 as "a;""b";`, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "dollar quoted function body",
+			content: "create function f() returns int as $$\nbegin\n  return 1;\nend;\n$$ language plpgsql;\nselect 2;",
+			want: []wantStatement{
+				{sql: "create function f() returns int as $$\nbegin\n  return 1;\nend;\n$$ language plpgsql;", line: 1, endLine: 5},
+				{sql: "select 2;", line: 6, endLine: 6},
+			},
+		},
+		{
+			name:    "tagged dollar quote containing $$",
+			content: "do $body$\nbegin\n  perform $$;$$;\nend\n$body$;\nselect 2;",
+			want: []wantStatement{
+				{sql: "do $body$\nbegin\n  perform $$;$$;\nend\n$body$;", line: 1, endLine: 5},
+				{sql: "select 2;", line: 6, endLine: 6},
+			},
+		},
+		{
+			name:    "positional parameters are not dollar quotes",
+			content: "select $1, a$b from t where x = $2;\nselect 2;",
+			want: []wantStatement{
+				{sql: "select $1, a$b from t where x = $2;", line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "nested block comments",
+			content: "select /* outer /* inner; */ still; */ 1;\nselect 2;",
+			want: []wantStatement{
+				{sql: "select /* outer /* inner; */ still; */ 1;", line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "semicolon in line comment",
+			content: "select 1 -- not; the end\n, 2;",
+			want:    []wantStatement{{sql: "select 1 -- not; the end\n, 2;", line: 1, endLine: 2}},
+		},
+		{
+			name:    "copy from stdin data",
+			content: "copy t (a, b) from stdin;\n1\tx;y\n2\tz\n\\.\nselect 2;",
+			want: []wantStatement{
+				{sql: "copy t (a, b) from stdin;", line: 1, endLine: 1, copyData: "1\tx;y\n2\tz\n"},
+				{sql: "select 2;", line: 5, endLine: 5},
+			},
+		},
+		{
+			name:    "copy from stdin without terminator",
+			content: "copy t from stdin;\n1\n2",
+			want:    []wantStatement{{sql: "copy t from stdin;", line: 1, endLine: 1, copyData: "1\n2"}},
+		},
+		{
+			name:    "psql meta-commands",
+			content: "\\set ON_ERROR_STOP on\nselect 1;\n  \\i other.sql\nselect 2;",
+			want: []wantStatement{
+				{sql: `\set ON_ERROR_STOP on`, kind: KindMetaCommand, line: 1, endLine: 1},
+				{sql: "select 1;", line: 2, endLine: 2},
+				{sql: `\i other.sql`, kind: KindMetaCommand, line: 3, endLine: 3},
+				{sql: "select 2;", line: 4, endLine: 4},
+			},
+		},
+		{
+			name:    "meta-command ends an unterminated statement",
+			content: "select 1\n\\gset\nselect 2;",
+			want: []wantStatement{
+				{sql: "select 1", line: 1, endLine: 1},
+				{sql: `\gset`, kind: KindMetaCommand, line: 2, endLine: 2},
+				{sql: "select 2;", line: 3, endLine: 3},
+			},
+		},
+		{
+			name:    "meta-command after sql on the same line ends the statement",
+			content: "select 1 \\gset\nselect 2;\nselect 3 as x \\gexec",
+			want: []wantStatement{
+				{sql: "select 1", line: 1, endLine: 1},
+				{sql: `\gset`, kind: KindMetaCommand, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+				{sql: "select 3 as x", line: 3, endLine: 3},
+				{sql: `\gexec`, kind: KindMetaCommand, line: 3, endLine: 3},
+			},
+		},
+		{
+			name:    "backslash in string does not end the statement",
+			content: `select E'\\g', '\g';` + "\nselect 2;",
+			want: []wantStatement{
+				{sql: `select E'\\g', '\g';`, line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "begin atomic function body",
+			content: "create function f(a int) returns int\nbegin atomic\n  select 1;\n  select case when a > 0 then a end;\nend;\nselect 2;",
+			want: []wantStatement{
+				{sql: "create function f(a int) returns int\nbegin atomic\n  select 1;\n  select case when a > 0 then a end;\nend;", line: 1, endLine: 5},
+				{sql: "select 2;", line: 6, endLine: 6},
+			},
+		},
+		{
+			name:    "begin and atomic as column names",
+			content: "create table t (begin int, atomic int);\nselect 2;",
+			want: []wantStatement{
+				{sql: "create table t (begin int, atomic int);", line: 1, endLine: 1},
+				{sql: "select 2;", line: 2, endLine: 2},
+			},
+		},
+		{
+			name:    "windows line endings",
+			content: "select 1;\r\nselect\r\n 2;\r\n",
+			want: []wantStatement{
+				{sql: "select 1;", line: 1, endLine: 1},
+				{sql: "select\r\n 2;", line: 2, endLine: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitStatements(tt.content)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d statements %#v, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				st := got[i]
+				if st.SQL != want.sql || st.Kind != want.kind || st.Line != want.line ||
+					st.EndLine != want.endLine || st.CopyData != want.copyData {
+					t.Errorf("statement %d = {%q kind=%d lines %d-%d copy=%q}, want {%q kind=%d lines %d-%d copy=%q}",
+						i, st.SQL, st.Kind, st.Line, st.EndLine, st.CopyData,
+						want.sql, want.kind, want.line, want.endLine, want.copyData)
+				}
+				if tt.content[st.Offset:st.End] != st.SQL && st.Kind == KindSQL {
+					t.Errorf("statement %d: offsets [%d, %d) give %q, want %q", i, st.Offset, st.End, tt.content[st.Offset:st.End], st.SQL)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/gitdiff"
 )
 
 // SQLFile представляет собой SQL-файл с его именем и содержимым
@@ -13,6 +15,18 @@ type SQLFile struct {
 	Content     string
 	Path        string
 	IsMigration bool
+	Migration   *Migration // Версия, направление и откат, если распознаны соглашения инструмента миграций
+	// ChangedLines строки, изменённые относительно базовой git-ревизии (режим ChangedFiles).
+	// nil или пустой список (изменённые строки не определены) означает, что ревьюится весь файл.
+	ChangedLines []gitdiff.LineRange
+	// Source исходные файл и строка для каждой строки Content после развёртывания \i и переменных.
+	// nil означает, что содержимое совпадает с файлом.
+	Source []SourceLine
+	// Embedded SQL извлечён из исходного кода: несколько записей относятся к одному файлу
+	// и различаются строкой
+	Embedded bool
+	// ExpandErr ошибка развёртывания \i, переменных или шаблона; такой файл не ревьюится
+	ExpandErr error
 }
 
 // SearchMode определяет режим поиска
@@ -22,6 +36,8 @@ const (
 	AllSQLFiles    SearchMode = iota // Все SQL файлы, кроме миграций
 	MigrationsOnly                   // Только миграции
 	SpecificFiles                    // Конкретные файлы
+	ChangedFiles                     // Файлы, добавленные или изменённые относительно git-ревизии
+	GoFiles                          // SQL из строковых констант Go-кода
 )
 
 // SearchConfig конфигурация поиска
@@ -29,9 +45,14 @@ type SearchConfig struct {
 	RootPath          string
 	Mode              SearchMode
 	MigrationsPath    string   // Путь к папке миграций (для MigrationsOnly)
+	MigrationsDirs    []string // Дополнительные каталоги миграций относительно RootPath
 	SpecificFileNames []string // Имена файлов для поиска (для SpecificFiles)
 	EnableIgnoreList  bool
-	IgnoreFiles       []string
+	IgnoreFiles       []string          // Шаблоны .gitignore, применяются вместе с .pgmonignore
+	Include           []string          // Шаблоны путей, которые ревьюятся; пусто — все .sql файлы
+	Exclude           []string          // Шаблоны путей, которые не ревьюятся
+	BaseRef           string            // Базовая git-ревизия (для ChangedFiles)
+	Vars              map[string]string // Переменные psql и text/template для развёртывания файлов
 }
 
 // normalizePath нормализует путь, убирая слеш в конце и добавляя ./ если нужно
@@ -69,58 +90,205 @@ func makeUniqueTitle(files []SQLFile, filename, fullPath string) string {
 	return fmt.Sprintf("%s_%s%s", name, relDir, ext)
 }
 
-// CollectSQLFiles запускает сбор файлов в зависимости от режима
+// CollectSQLFiles запускает сбор файлов в зависимости от режима и разворачивает их
+// (\i, переменные psql и плейсхолдеры шаблонов) перед ревью. Ошибки развёртывания
+// не прерывают сбор и сохраняются в SQLFile.ExpandErr.
 func CollectSQLFiles(config SearchConfig) ([]SQLFile, error) {
+	files, err := collectFiles(config)
+	if err != nil {
+		return nil, err
+	}
+	// SQL из Go-кода не разворачивается: Source уже указывает на строки Go-файла
+	if config.Mode == GoFiles {
+		return files, nil
+	}
+	ExpandFiles(files, config.Vars)
+	return files, nil
+}
+
+// collectFiles собирает файлы без развёртывания
+func collectFiles(config SearchConfig) ([]SQLFile, error) {
 	switch config.Mode {
 	case MigrationsOnly:
 		return collectMigrations(config)
 	case SpecificFiles:
 		return collectSpecificFiles(config)
+	case ChangedFiles:
+		return collectChangedFiles(config)
+	case GoFiles:
+		return collectGoFiles(config)
 	default:
 		return collectAllSQLFiles(config)
 	}
 }
 
-// collectMigrations собирает только файлы миграций
+// collectMigrations собирает файлы миграций и упорядочивает их по версиям
+// с учётом соглашений golang-migrate, goose, Flyway, sqitch и Liquibase
 func collectMigrations(config SearchConfig) ([]SQLFile, error) {
-	var files []SQLFile
-
-	migrationsPath := config.MigrationsPath
-	if migrationsPath == "" {
-		migrationsPath = filepath.Join(config.RootPath, "migrations")
-	} else {
-		migrationsPath = normalizePath(migrationsPath)
-		if !filepath.IsAbs(migrationsPath) {
-			migrationsPath = filepath.Join(config.RootPath, migrationsPath)
-		}
+	filter, err := newPathFilter(config)
+	if err != nil {
+		return nil, err
 	}
 
-	err := filepath.Walk(migrationsPath, func(path string, info os.FileInfo, err error) error {
+	var files []migrationFile
+	titles := make([]SQLFile, 0)
+	for _, migrationsPath := range migrationDirs(config) {
+		plan, err := sqitchPlan(migrationsPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".sql") {
-			if config.EnableIgnoreList && contains(config.IgnoreFiles, info.Name()) {
-				return nil
-			}
 
+		err = walkSQLFiles(config, filter, migrationsPath, func(path string) error {
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return err
 			}
 
-			title := makeUniqueTitle(files, filepath.Base(path), path)
-			files = append(files, SQLFile{
+			title := makeUniqueTitle(titles, filepath.Base(path), path)
+			file := SQLFile{
 				Title:       title,
 				Content:     string(content),
 				Path:        path,
 				IsMigration: true,
-			})
+			}
+			titles = append(titles, file)
+
+			if f, ok := newMigrationFile(migrationsPath, plan, file); ok {
+				files = append(files, f)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		return nil
+	}
+
+	return orderMigrations(files), nil
+}
+
+// migrationDirs возвращает каталоги миграций: --vp и MigrationsDirs относительно корня поиска
+// или <root>/migrations, если каталоги не заданы
+func migrationDirs(config SearchConfig) []string {
+	var dirs []string
+	for _, dir := range append([]string{config.MigrationsPath}, config.MigrationsDirs...) {
+		if dir == "" {
+			continue
+		}
+		path := normalizePath(dir)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(config.RootPath, path)
+		}
+		dirs = append(dirs, path)
+	}
+	if len(dirs) == 0 {
+		return []string{filepath.Join(config.RootPath, "migrations")}
+	}
+	return dirs
+}
+
+// isMigrationPath сообщает, относится ли файл к миграциям. Если каталоги миграций заданы явно,
+// файл должен лежать в одном из них; иначе миграциями считаются файлы в каталогах с именем migrations.
+func isMigrationPath(config SearchConfig, path string) bool {
+	if config.MigrationsPath == "" && len(config.MigrationsDirs) == 0 {
+		rel := relPath(config.RootPath, config.RootPath, path)
+		dirs := strings.Split(rel, "/")
+		for _, dir := range dirs[:len(dirs)-1] {
+			if strings.EqualFold(dir, "migrations") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, dir := range migrationDirs(config) {
+		if isWithin(dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin сообщает, лежит ли path внутри каталога dir
+func isWithin(dir, path string) bool {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// relPath возвращает путь относительно корня поиска через / для сопоставления с шаблонами.
+// Файлы вне корня (например, абсолютный --vp) сопоставляются относительно обходимого каталога.
+func relPath(root, dir, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if rel, err = filepath.Rel(dir, path); err != nil {
+			return filepath.ToSlash(path)
+		}
+	}
+	return filepath.ToSlash(rel)
+}
+
+// walkSQLFiles обходит каталог dir и вызывает fn для каждого .sql файла, не исключённого фильтром.
+// Исключённые каталоги не обходятся.
+func walkSQLFiles(config SearchConfig, filter *pathFilter, dir string, fn func(path string) error) error {
+	return walkFiles(config, filter, dir, ".sql", fn)
+}
+
+// walkFiles обходит каталог dir и вызывает fn для каждого файла с расширением ext,
+// не исключённого фильтром
+func walkFiles(config SearchConfig, filter *pathFilter, dir, ext string, fn func(path string) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(config.RootPath, dir, path)
+		if info.IsDir() {
+			if path != dir && filter.skipDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), ext) || filter.skipFile(rel) {
+			return nil
+		}
+		return fn(path)
 	})
+}
 
-	return files, err
+// newMigrationFile распознаёт соглашения инструмента миграций для файла.
+// Возвращает false для файлов, которые не ревьюятся (verify-скрипты sqitch).
+func newMigrationFile(migrationsPath string, plan []string, file SQLFile) (migrationFile, bool) {
+	f := migrationFile{SQLFile: file}
+
+	m, ok := Migration{}, false
+	if plan != nil {
+		m, ok = detectSqitch(migrationsPath, file.Path, plan)
+		if ok && m.Direction == "" {
+			return f, false
+		}
+	}
+	if !ok {
+		m = DetectMigration(filepath.Base(file.Path), file.Content)
+	}
+	if m.Tool == ToolGoose {
+		f.Content, m.Down = splitGoose(f.Content)
+	}
+	if m.Tool == ToolFlyway && m.Transactional {
+		m.Transactional = flywayScriptConfig(file.Path)
+	}
+
+	f.Migration = m
+	switch {
+	case m.Tool == ToolSqitch:
+		f.key = string(m.Tool) + ":" + m.Description
+	case m.Version != "":
+		f.key = string(m.Tool) + ":" + m.Version
+	}
+	return f, true
 }
 
 // collectSpecificFiles собирает только указанные файлы
@@ -132,35 +300,28 @@ func collectSpecificFiles(config SearchConfig) ([]SQLFile, error) {
 		targetFiles[strings.ToLower(filename)] = true
 	}
 
-	err := filepath.Walk(config.RootPath, func(path string, info os.FileInfo, err error) error {
+	filter, err := newPathFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkSQLFiles(config, filter, config.RootPath, func(path string) error {
+		if isMigrationPath(config, path) || !targetFiles[strings.ToLower(filepath.Base(path))] {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".sql") {
-			if strings.Contains(strings.ToLower(path), "migrations") ||
-				strings.Contains(strings.ToLower(info.Name()), "migration") {
-				return nil
-			}
 
-			if targetFiles[strings.ToLower(info.Name())] {
-				if config.EnableIgnoreList && contains(config.IgnoreFiles, info.Name()) {
-					return nil
-				}
-
-				content, err := os.ReadFile(path)
-				if err != nil {
-					return err
-				}
-
-				title := makeUniqueTitle(files, filepath.Base(path), path)
-				files = append(files, SQLFile{
-					Title:       title,
-					Content:     string(content),
-					Path:        path,
-					IsMigration: false,
-				})
-			}
-		}
+		title := makeUniqueTitle(files, filepath.Base(path), path)
+		files = append(files, SQLFile{
+			Title:       title,
+			Content:     string(content),
+			Path:        path,
+			IsMigration: false,
+		})
 		return nil
 	})
 
@@ -171,45 +332,39 @@ func collectSpecificFiles(config SearchConfig) ([]SQLFile, error) {
 func collectAllSQLFiles(config SearchConfig) ([]SQLFile, error) {
 	var files []SQLFile
 
-	err := filepath.Walk(config.RootPath, func(path string, info os.FileInfo, err error) error {
+	filter, err := newPathFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkSQLFiles(config, filter, config.RootPath, func(path string) error {
+		if isMigrationPath(config, path) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".sql") {
-			if strings.Contains(strings.ToLower(path), "migrations") ||
-				strings.Contains(strings.ToLower(info.Name()), "migration") {
-				return nil
-			}
-
-			if config.EnableIgnoreList && contains(config.IgnoreFiles, info.Name()) {
-				return nil
-			}
 
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-
-			title := makeUniqueTitle(files, filepath.Base(path), path)
-			files = append(files, SQLFile{
-				Title:       title,
-				Content:     string(content),
-				Path:        path,
-				IsMigration: false,
-			})
-		}
+		title := makeUniqueTitle(files, filepath.Base(path), path)
+		files = append(files, SQLFile{
+			Title:       title,
+			Content:     string(content),
+			Path:        path,
+			IsMigration: false,
+		})
 		return nil
 	})
 
 	return files, err
 }
 
-// contains проверяет наличие элемента в срезе
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+// FirstStatementLine возвращает номер строки (с 1), с которой начинается первый SQL-оператор,
+// пропуская пустые строки и комментарии
+func FirstStatementLine(content string) int {
+	if stmts := SplitStatements(content); len(stmts) > 0 {
+		return stmts[0].Line
 	}
-	return false
+	return 1
 }
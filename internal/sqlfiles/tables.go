@@ -0,0 +1,188 @@
+package sqlfiles
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableRef ссылка на таблицу в SQL-запросе
+type TableRef struct {
+	Schema string
+	Name   string
+}
+
+// String возвращает имя таблицы в виде schema.name (или name без схемы)
+func (t TableRef) String() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// tokenPattern идентификаторы (возможно, с кавычками и схемой) и отдельные символы
+var tokenPattern = regexp.MustCompile(`(?:"[^"]*"|[a-z_][a-z0-9_$]*)(?:\.(?:"[^"]*"|[a-z_][a-z0-9_$]*))*|\S`)
+
+// tableKeywords слова, после которых следует имя таблицы
+var tableKeywords = map[string]bool{
+	"from": true, "join": true, "update": true, "into": true, "table": true, "truncate": true,
+}
+
+// skipKeywords модификаторы между ключевым словом и именем таблицы
+var skipKeywords = map[string]bool{
+	"only": true, "if": true, "not": true, "exists": true, "lateral": true, "table": true,
+}
+
+// reservedWords слова, которые не могут быть именем таблицы или псевдонимом
+var reservedWords = map[string]bool{
+	"select": true, "where": true, "on": true, "using": true, "join": true, "left": true, "right": true,
+	"inner": true, "outer": true, "full": true, "cross": true, "natural": true, "group": true, "order": true,
+	"limit": true, "offset": true, "having": true, "union": true, "except": true, "intersect": true,
+	"set": true, "values": true, "returning": true, "window": true, "for": true, "as": true, "from": true,
+	"default": true, "lateral": true, "with": true, "fetch": true, "into": true,
+}
+
+// fromFunctions функции, в аргументах которых FROM не означает таблицу
+var fromFunctions = map[string]bool{
+	"extract": true, "substring": true, "trim": true, "overlay": true, "position": true,
+}
+
+// ReferencedTables возвращает таблицы, на которые ссылается SQL: после FROM, JOIN,
+// UPDATE, INTO, TABLE и TRUNCATE, включая списки через запятую. Имена CTE,
+// подзапросы и табличные функции пропускаются. Порядок — по первому упоминанию.
+func ReferencedTables(sql string) []TableRef {
+	tokens := tokenPattern.FindAllString(NormalizeQuery(sql), -1)
+
+	ctes := make(map[string]bool)
+	for i := 0; i+2 < len(tokens); i++ {
+		if isIdent(tokens[i]) && tokens[i+1] == "as" && tokens[i+2] == "(" {
+			ctes[tokens[i]] = true
+		}
+	}
+
+	var refs []TableRef
+	seen := make(map[TableRef]bool)
+	add := func(tok string) {
+		ref := parseTableRef(tok)
+		if ref.Schema == "" && ctes[ref.Name] {
+			return
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	// tableAt возвращает позицию после имени таблицы или -1, если это не таблица
+	tableAt := func(i int) int {
+		for i < len(tokens) && skipKeywords[tokens[i]] {
+			i++
+		}
+		if i < len(tokens) && tokens[i] == "(" {
+			// Подзапрос: таблицы внутри найдутся по своим FROM
+			return skipParens(tokens, i)
+		}
+		if i >= len(tokens) || !isIdent(tokens[i]) || reservedWords[tokens[i]] {
+			return -1
+		}
+		if i+1 < len(tokens) && tokens[i+1] == "(" {
+			// Табличная функция или список колонок INSERT INTO t (...)
+			if i > 0 && tokens[i-1] == "into" {
+				add(tokens[i])
+			}
+			return skipParens(tokens, i+1)
+		}
+		add(tokens[i])
+		return i + 1
+	}
+
+	// Стек скобок: true, если скобка открыта функцией с синтаксисом "x FROM y"
+	var parens []bool
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(":
+			parens = append(parens, i > 0 && fromFunctions[tokens[i-1]])
+		case ")":
+			if len(parens) > 0 {
+				parens = parens[:len(parens)-1]
+			}
+		}
+		if !tableKeywords[tokens[i]] || (len(parens) > 0 && parens[len(parens)-1]) {
+			continue
+		}
+		next := tableAt(i + 1)
+		if tokens[i] != "from" {
+			continue
+		}
+
+		// FROM a x, b AS y, c
+		for next > 0 && next < len(tokens) {
+			if tokens[next] == "as" {
+				next++
+			}
+			if next < len(tokens) && isIdent(tokens[next]) && !reservedWords[tokens[next]] {
+				next++
+			}
+			if next >= len(tokens) || tokens[next] != "," {
+				break
+			}
+			next = tableAt(next + 1)
+		}
+	}
+
+	return refs
+}
+
+// skipParens возвращает позицию после скобки, парной открывающей в позиции i
+func skipParens(tokens []string, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// isIdent сообщает, является ли токен идентификатором
+func isIdent(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	c := tok[0]
+	return c == '"' || c == '_' || (c >= 'a' && c <= 'z')
+}
+
+// parseTableRef разбирает schema.name; кавычки вокруг имён снимаются
+func parseTableRef(tok string) TableRef {
+	parts := splitQualified(tok)
+	ref := TableRef{Name: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		ref.Schema = parts[len(parts)-2]
+	}
+	return ref
+}
+
+// splitQualified делит имя по точкам вне кавычек
+func splitQualified(tok string) []string {
+	var parts []string
+	var b strings.Builder
+	quoted := false
+	for _, c := range tok {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '.' && !quoted:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return append(parts, b.String())
+}
@@ -0,0 +1,10 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+)
+
+func Daily(ctx context.Context, conn *sql.DB) (*sql.Rows, error) {
+	return conn.QueryContext(ctx, "SELECT day, sum(total) FROM orders GROUP BY day")
+}
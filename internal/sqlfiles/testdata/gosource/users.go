@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+)
+
+const usersColumns = "id, email"
+
+const (
+	selectUsers = "SELECT " + usersColumns + " FROM users"
+	userByID    = selectUsers + " WHERE id = $1"
+)
+
+type Repo struct {
+	conn *sql.DB
+}
+
+func (r *Repo) ByID(ctx context.Context, id int64) *sql.Row {
+	return r.conn.QueryRowContext(ctx, userByID, id)
+}
+
+func (r *Repo) All() (*sql.Rows, error) {
+	return r.conn.Query("SELECT id FROM users ORDER BY id")
+}
+
+func (r *Repo) Deactivate(ctx context.Context, id int64) error {
+	_, err := r.conn.ExecContext(ctx, `UPDATE users
+SET active = false
+WHERE id = $1`, id)
+	return err
+}
+
+func (r *Repo) Prepare(ctx context.Context) (*sql.Stmt, error) {
+	return r.conn.PrepareContext(ctx, "DELETE FROM sessions WHERE user_id = $1")
+}
+
+func (r *Repo) Named() db.Query {
+	return db.Query{
+		Name: "users.count",
+		Raw:  "SELECT count(*) FROM users",
+	}
+}
+
+func (r *Repo) Dynamic(ctx context.Context, table string) error {
+	_, err := r.conn.ExecContext(ctx, fmt.Sprintf("TRUNCATE %s", table))
+	return err
+}
+
+func (r *Repo) NotSQL(ctx context.Context) error {
+	_, err := r.conn.ExecContext(ctx, "hello world")
+	return err
+}
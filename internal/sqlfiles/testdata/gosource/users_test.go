@@ -0,0 +1,8 @@
+package repo
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	var r Repo
+	r.conn.Query("SELECT 1 FROM test_only")
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+)
+
+// FileReviews собирает записи для сохранения из результатов ревью SQL-файлов.
+// Заголовок (ключ треда) и миграция берутся из самого результата: путь результата может указывать
+// на подключённый через \i файл, а у запросов из Go-кода один путь на несколько файлов.
+func FileReviews(sourceDatabase, environment string, schedulerTask bool, results []client.FileResult) []Review {
+	reviews := make([]Review, 0, len(results))
+	for _, r := range results {
+		review := Review{
+			SourceDatabase: sourceDatabase,
+			Environment:    environment,
+			SQL:            r.SQL,
+			ThreadID:       r.Title,
+			Notes:          r.Path,
+			SchedulerTask:  schedulerTask,
+			Tables:         r.Tables,
+		}
+		if r.QueryPlan != nil {
+			if plan, err := json.Marshal(r.QueryPlan); err == nil {
+				review.QueryPlan = string(plan)
+			}
+		}
+
+		if r.IsMigration {
+			req := models.MigrationReviewRequest{SQL: r.SQL, Environment: environment}
+			if r.Migration != nil {
+				req.RollbackSQL = r.Migration.Down
+				req.Transactional = r.Migration.Transactional
+			}
+			review.Request = req
+		} else {
+			review.Request = models.QueryReviewRequest{SQL: r.SQL, QueryPlan: r.QueryPlan, Tables: r.Tables, ThreadID: r.Title, Environment: environment}
+		}
+
+		switch {
+		case r.Failed():
+			review.Errors = []string{r.Err.Error()}
+			review.Response = map[string]string{"error": r.Err.Error()}
+		case r.IsMigration:
+			review.Score = intPtr(r.Score)
+			review.Response = models.MigrationReviewResponse{
+				Score:           r.Score,
+				Recommendations: r.Recommendations,
+				Issues:          r.Issues,
+				Warnings:        r.Warnings,
+			}
+		default:
+			review.Score = intPtr(r.Score)
+			review.Response = models.QueryReviewResponse{
+				Score:           r.Score,
+				Recommendations: r.Recommendations,
+				Issues:          r.Issues,
+			}
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews
+}
+
+// SaveReviews сохраняет записи по очереди и возвращает первую ошибку
+func (s *Store) SaveReviews(ctx context.Context, reviews []Review) error {
+	for _, r := range reviews {
+		if _, err := s.SaveReview(ctx, r); err != nil {
+			return fmt.Errorf("failed to save review of %s: %w", r.Notes, err)
+		}
+	}
+	return nil
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	client "github.com/ratmirtech/postgresql-query-monitor/internal/review"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+func TestFileReviewsKeepsRemappedAndEmbeddedResults(t *testing.T) {
+	results := []client.FileResult{
+		// Оператор целиком из файла, подключённого через \i: путь указывает на него
+		{Path: "sql/common/users.sql", Title: "report", Statement: 2, SQL: "select 1"},
+		// Два запроса из одного Go-файла
+		{Path: "repo/users.go", Title: "users.go:12", Embedded: true, SQL: "select 2"},
+		{Path: "repo/users.go", Title: "users.go:30", Embedded: true, SQL: "select 3"},
+		{
+			Path: "migrations/001_init.sql", Title: "001_init", IsMigration: true, SQL: "create table t ()",
+			Migration: &sqlfiles.Migration{Down: "drop table t"},
+		},
+	}
+
+	reviews := FileReviews("db", "test", false, results)
+	if len(reviews) != len(results) {
+		t.Fatalf("got %d reviews, want %d", len(reviews), len(results))
+	}
+
+	for i, want := range []string{"report", "users.go:12", "users.go:30", "001_init"} {
+		if reviews[i].ThreadID != want {
+			t.Errorf("review %d: thread %q, want %q", i, reviews[i].ThreadID, want)
+		}
+	}
+	if q, ok := reviews[2].Request.(models.QueryReviewRequest); !ok || q.SQL != "select 3" || q.ThreadID != "users.go:30" {
+		t.Errorf("unexpected request of the second embedded query: %#v", reviews[2].Request)
+	}
+	if m, ok := reviews[3].Request.(models.MigrationReviewRequest); !ok || m.RollbackSQL != "drop table t" {
+		t.Errorf("migration request lost its rollback: %#v", reviews[3].Request)
+	}
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+// Filter условия выборки сохранённых ревью. Пустые поля не ограничивают выборку.
+type Filter struct {
+	SourceDatabase string
+	Environment    string
+	Since          time.Time
+	Until          time.Time
+	MinScore       *int
+	MaxScore       *int
+	Path           string // Подстрока пути к файлу (колонка notes)
+	ThreadID       string // thread_id из запроса к Review API
+	Issue          string // Точный текст проблемы из ответа Review API
+	Limit          int
+}
+
+// Record сохранённое ревью
+type Record struct {
+	ID              int64     `json:"id"`
+	SourceDatabase  string    `json:"source_database"`
+	Environment     string    `json:"environment"`
+	Path            string    `json:"path,omitempty"`
+	SQL             string    `json:"sql"`
+	Fingerprint     string    `json:"fingerprint"`
+	Score           *int      `json:"score"`
+	Issues          []string  `json:"issues,omitempty"`
+	Warnings        []string  `json:"warnings,omitempty"`
+	Recommendations []string  `json:"recommendations,omitempty"`
+	Errors          []string  `json:"errors,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// where собирает условие WHERE и аргументы выборки по фильтру
+func (f Filter) where() (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.SourceDatabase != "" {
+		add("source_database = $%d", f.SourceDatabase)
+	}
+	if f.Environment != "" {
+		add("environment = $%d", f.Environment)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >= $%d", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("created_at < $%d", f.Until)
+	}
+	if f.MinScore != nil {
+		add("overall_score >= $%d", *f.MinScore)
+	}
+	if f.MaxScore != nil {
+		add("overall_score <= $%d", *f.MaxScore)
+	}
+	if f.Path != "" {
+		add("strpos(notes, $%d) > 0", f.Path)
+	}
+	if f.ThreadID != "" {
+		add("request_json @> jsonb_build_object('thread_id', $%d::text)", f.ThreadID)
+	}
+	if f.Issue != "" {
+		add("response_json @> jsonb_build_object('issues', jsonb_build_array($%d::text))", f.Issue)
+	}
+
+	if len(where) == 0 {
+		return "", nil
+	}
+	return "\n\t\tWHERE " + strings.Join(where, " AND "), args
+}
+
+// ListReviews возвращает сохранённые ревью по фильтру, новые первыми.
+// Фильтры по thread_id и тексту проблемы используют GIN-индексы request_json и response_json.
+func (s *Store) ListReviews(ctx context.Context, f Filter) ([]Record, error) {
+	where, args := f.where()
+	raw := `
+		SELECT id, source_database, environment, COALESCE(notes, ''), sql_query,
+			overall_score, response_json::text, COALESCE(errors, '{}'), created_at
+		FROM query_reviews` + where
+	raw += "\n\t\tORDER BY created_at DESC, id DESC"
+	if f.Limit > 0 {
+		raw += fmt.Sprintf("\n\t\tLIMIT %d", f.Limit)
+	}
+
+	rows, err := s.client.QueryContext(ctx, db.Query{Name: "list_query_reviews", Raw: raw}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var response string
+		if err := rows.Scan(&r.ID, &r.SourceDatabase, &r.Environment, &r.Path, &r.SQL,
+			&r.Score, &response, &r.Errors, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+
+		var body struct {
+			Issues          []string `json:"issues"`
+			Warnings        []string `json:"warnings"`
+			Recommendations []string `json:"recommendations"`
+		}
+		// Ответы с ошибкой ревью не содержат этих полей
+		_ = json.Unmarshal([]byte(response), &body)
+		r.Issues, r.Warnings, r.Recommendations = body.Issues, body.Warnings, body.Recommendations
+		r.Fingerprint = sqlfiles.Fingerprint(r.SQL)
+
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review rows: %w", err)
+	}
+
+	return records, nil
+}
+
+// TrendPoint оценка запроса в момент ревью
+type TrendPoint struct {
+	CreatedAt time.Time `json:"created_at"`
+	Score     int       `json:"score"`
+}
+
+// Trend динамика оценки одного запроса (по отпечатку SQL) во времени
+type Trend struct {
+	Fingerprint string       `json:"fingerprint"`
+	Path        string       `json:"path,omitempty"`
+	SQL         string       `json:"sql"`
+	Reviews     int          `json:"reviews"`
+	First       int          `json:"first"`
+	Last        int          `json:"last"`
+	Min         int          `json:"min"`
+	Max         int          `json:"max"`
+	Delta       int          `json:"delta"`
+	Points      []TrendPoint `json:"points"`
+}
+
+// ListTrends строит динамику оценок по всем ревью, подходящим под фильтр.
+// Limit ограничивает число запросов в результате, а не число ревью: иначе динамика
+// строилась бы только по последним ревью и теряла бы ранние оценки.
+func (s *Store) ListTrends(ctx context.Context, f Filter) ([]Trend, error) {
+	where, args := f.where()
+	if where == "" {
+		where = "\n\t\tWHERE overall_score IS NOT NULL"
+	} else {
+		where += " AND overall_score IS NOT NULL"
+	}
+	raw := `
+		SELECT COALESCE(notes, ''), sql_query, overall_score, created_at
+		FROM query_reviews` + where + "\n\t\tORDER BY created_at, id"
+
+	rows, err := s.client.QueryContext(ctx, db.Query{Name: "list_query_review_trends", Raw: raw}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review scores: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.Path, &r.SQL, &r.Score, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review score: %w", err)
+		}
+		r.Fingerprint = sqlfiles.Fingerprint(r.SQL)
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review score rows: %w", err)
+	}
+
+	trends := Trends(records)
+	if f.Limit > 0 && len(trends) > f.Limit {
+		trends = trends[:f.Limit]
+	}
+	return trends, nil
+}
+
+// Trends группирует ревью по отпечатку SQL и строит динамику оценок.
+// Ревью без оценки не учитываются. Сортировка — по убыванию изменения оценки в худшую сторону.
+func Trends(records []Record) []Trend {
+	sorted := make([]Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	byFingerprint := make(map[string]*Trend)
+	var order []string
+	for _, r := range sorted {
+		if r.Score == nil {
+			continue
+		}
+		t, ok := byFingerprint[r.Fingerprint]
+		if !ok {
+			t = &Trend{Fingerprint: r.Fingerprint, SQL: r.SQL, First: *r.Score, Min: *r.Score, Max: *r.Score}
+			byFingerprint[r.Fingerprint] = t
+			order = append(order, r.Fingerprint)
+		}
+
+		t.Points = append(t.Points, TrendPoint{CreatedAt: r.CreatedAt, Score: *r.Score})
+		t.Reviews++
+		t.Last = *r.Score
+		t.Path = r.Path
+		if *r.Score < t.Min {
+			t.Min = *r.Score
+		}
+		if *r.Score > t.Max {
+			t.Max = *r.Score
+		}
+	}
+
+	trends := make([]Trend, 0, len(order))
+	for _, fp := range order {
+		t := byFingerprint[fp]
+		t.Delta = t.Last - t.First
+		trends = append(trends, *t)
+	}
+
+	sort.SliceStable(trends, func(i, j int) bool { return trends[i].Delta < trends[j].Delta })
+	return trends
+}
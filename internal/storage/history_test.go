@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/sqlfiles"
+)
+
+func TestFilterWhere(t *testing.T) {
+	if where, args := (Filter{Limit: 10}).where(); where != "" || args != nil {
+		t.Errorf("empty filter: got %q %v", where, args)
+	}
+
+	minScore := 50
+	where, args := Filter{SourceDatabase: "db", MinScore: &minScore, Path: "reports/"}.where()
+	want := "WHERE source_database = $1 AND overall_score >= $2 AND strpos(notes, $3) > 0"
+	if strings.TrimSpace(where) != want {
+		t.Errorf("got %q, want %q", strings.TrimSpace(where), want)
+	}
+	if len(args) != 3 || args[0] != "db" || args[1] != 50 || args[2] != "reports/" {
+		t.Errorf("got args %v", args)
+	}
+}
+
+func TestTrends(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2025, 1, day, 0, 0, 0, 0, time.UTC) }
+	score := func(s int) *int { return &s }
+	records := []Record{
+		// Новые первыми, как возвращает ListReviews
+		{SQL: "select * from a where id = 2", Score: score(40), CreatedAt: at(3), Path: "a.sql"},
+		{SQL: "select * from b", Score: score(90), CreatedAt: at(3)},
+		{SQL: "select * from a where id = 1", Score: nil, CreatedAt: at(2)},
+		{SQL: "SELECT * FROM a WHERE id = 1", Score: score(80), CreatedAt: at(1), Path: "old/a.sql"},
+		{SQL: "select * from b", Score: score(70), CreatedAt: at(1)},
+	}
+	for i := range records {
+		records[i].Fingerprint = sqlfiles.Fingerprint(records[i].SQL)
+	}
+
+	trends := Trends(records)
+	if len(trends) != 2 {
+		t.Fatalf("got %d trends, want 2", len(trends))
+	}
+
+	a := trends[0]
+	if a.Reviews != 2 || a.First != 80 || a.Last != 40 || a.Delta != -40 || a.Min != 40 || a.Max != 80 || a.Path != "a.sql" {
+		t.Errorf("worsened query: %+v", a)
+	}
+	if len(a.Points) != 2 || !a.Points[0].CreatedAt.Equal(at(1)) {
+		t.Errorf("points should be ordered by time: %+v", a.Points)
+	}
+	if b := trends[1]; b.Delta != 20 {
+		t.Errorf("improved query: %+v", b)
+	}
+}
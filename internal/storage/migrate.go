@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/ratmirtech/postgresql-query-monitor/migrations"
+)
+
+// schemaMigrationsTable таблица учёта применённых миграций
+const schemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	)`
+
+// Migrate применяет встроенные миграции, которые ещё не отмечены в schema_migrations.
+// Каждый файл выполняется одним запросом вместе с записью версии, поэтому
+// применяется целиком или не применяется вовсе.
+func Migrate(ctx context.Context, client db.DB) error {
+	return migrate(ctx, client, migrations.FS)
+}
+
+func migrate(ctx context.Context, client db.DB, files fs.FS) error {
+	if _, err := client.ExecContext(ctx, db.Query{Name: "create_schema_migrations", Raw: schemaMigrationsTable}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+		if applied[version] {
+			continue
+		}
+
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		// Запрос без параметров выполняется по простому протоколу: несколько
+		// операторов в одной неявной транзакции
+		raw := fmt.Sprintf("%s\n;\nINSERT INTO schema_migrations (version) VALUES (%s)", content, quoteLiteral(version))
+		if _, err := client.ExecContext(ctx, db.Query{Name: "migration_" + version, Raw: raw}); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// appliedVersions возвращает версии уже применённых миграций
+func appliedVersions(ctx context.Context, client db.DB) (map[string]bool, error) {
+	rows, err := client.QueryContext(ctx, db.Query{
+		Name: "get_schema_migrations",
+		Raw:  "SELECT version FROM schema_migrations",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating migration rows: %w", err)
+	}
+
+	return applied, nil
+}
+
+// quoteLiteral экранирует строку как SQL-литерал
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+)
+
+// RetentionPolicy политика хранения ревью. Строка удаляется, если подпадает
+// хотя бы под одно включённое правило; нулевые значения правило отключают.
+type RetentionPolicy struct {
+	MaxAge     time.Duration // Хранить не дольше указанного срока
+	KeepLast   int           // Хранить не больше N последних ревью на каждый source_database
+	Downsample bool          // Оставлять от плановых (--st) запусков одно последнее ревью запроса в сутки
+}
+
+// Enabled сообщает, задано ли хотя бы одно правило
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.KeepLast > 0 || p.Downsample
+}
+
+// PruneOptions параметры очистки
+type PruneOptions struct {
+	ArchiveDir string // Каталог для архивов .jsonl.gz; пустой — удалять без архивации
+	DryRun     bool   // Только посчитать строки, ничего не удалять
+}
+
+// PruneResult результат очистки
+type PruneResult struct {
+	Matched     int    `json:"matched"`
+	Deleted     int    `json:"deleted"`
+	ArchivePath string `json:"archive_path,omitempty"`
+}
+
+// Prune выбирает ревью по политике хранения, архивирует их в сжатый JSONL
+// (вместе со связанными table_structures) и удаляет из базы.
+// Строки удаляются только после успешной записи архива.
+func (s *Store) Prune(ctx context.Context, policy RetentionPolicy, opts PruneOptions, now time.Time) (PruneResult, error) {
+	var result PruneResult
+	if !policy.Enabled() {
+		return result, nil
+	}
+
+	ids, err := s.pruneCandidates(ctx, policy, now)
+	if err != nil {
+		return result, err
+	}
+	result.Matched = len(ids)
+	if len(ids) == 0 || opts.DryRun {
+		return result, nil
+	}
+
+	if opts.ArchiveDir != "" {
+		path, err := s.archive(ctx, ids, opts.ArchiveDir, now)
+		if err != nil {
+			return result, err
+		}
+		result.ArchivePath = path
+	}
+
+	tag, err := s.client.ExecContext(ctx, db.Query{
+		Name: "delete_query_reviews",
+		Raw:  "DELETE FROM query_reviews WHERE id = ANY($1)",
+	}, ids)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete reviews: %w", err)
+	}
+	result.Deleted = int(tag.RowsAffected())
+
+	return result, nil
+}
+
+// pruneCandidates возвращает id ревью, подпадающих под политику
+func (s *Store) pruneCandidates(ctx context.Context, policy RetentionPolicy, now time.Time) ([]int64, error) {
+	var parts []string
+	var args []interface{}
+
+	if policy.MaxAge > 0 {
+		args = append(args, now.Add(-policy.MaxAge))
+		parts = append(parts, fmt.Sprintf("SELECT id FROM query_reviews WHERE created_at < $%d", len(args)))
+	}
+	if policy.KeepLast > 0 {
+		args = append(args, policy.KeepLast)
+		parts = append(parts, fmt.Sprintf(`
+			SELECT id FROM (
+				SELECT id, row_number() OVER (
+					PARTITION BY source_database ORDER BY created_at DESC, id DESC
+				) AS rn
+				FROM query_reviews
+			) ranked WHERE rn > $%d`, len(args)))
+	}
+	if policy.Downsample {
+		// Текущие сутки не прореживаются: за них ещё могут прийти запуски
+		args = append(args, now)
+		parts = append(parts, fmt.Sprintf(`
+			SELECT id FROM (
+				SELECT id, row_number() OVER (
+					PARTITION BY source_database, environment, md5(sql_query), date_trunc('day', created_at)
+					ORDER BY created_at DESC, id DESC
+				) AS rn
+				FROM query_reviews
+				WHERE scheduler_task AND created_at < date_trunc('day', $%d::timestamptz)
+			) daily WHERE rn > 1`, len(args)))
+	}
+
+	rows, err := s.client.QueryContext(ctx, db.Query{
+		Name: "select_prune_candidates",
+		Raw:  strings.Join(parts, "\n\t\t\tUNION\n") + "\n\t\t\tORDER BY id",
+	}, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select reviews for pruning: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan review id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// archiveQuery строка ревью вместе с table_structures в виде одного JSON-объекта
+const archiveQuery = `
+	SELECT jsonb_build_object(
+		'review', to_jsonb(q),
+		'tables', COALESCE(
+			(SELECT jsonb_agg(to_jsonb(t) ORDER BY t.id) FROM table_structures t WHERE t.review_id = q.id),
+			'[]'::jsonb
+		)
+	)::text
+	FROM query_reviews q
+	WHERE q.id = ANY($1)
+	ORDER BY q.id`
+
+// archive записывает ревью в файл query_reviews-<время>.jsonl.gz и возвращает его путь.
+// Файл пишется во временный и переименовывается после успешного закрытия.
+func (s *Store) archive(ctx context.Context, ids []int64, dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("query_reviews-%s.jsonl.gz", now.UTC().Format("20060102T150405Z")))
+	tmp, err := os.CreateTemp(dir, ".query_reviews-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.writeArchive(ctx, tmp, ids); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to move archive file: %w", err)
+	}
+
+	return path, nil
+}
+
+func (s *Store) writeArchive(ctx context.Context, file *os.File, ids []int64) error {
+	gz := gzip.NewWriter(file)
+	buf := bufio.NewWriter(gz)
+
+	rows, err := s.client.QueryContext(ctx, db.Query{Name: "archive_query_reviews", Raw: archiveQuery}, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query reviews for archive: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return fmt.Errorf("failed to scan archived review: %w", err)
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating archived reviews: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return nil
+}
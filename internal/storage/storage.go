@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/dreadew/go-common/pkg/clients/db"
+	"github.com/dreadew/go-common/pkg/clients/db/impl"
+	"github.com/hashicorp/vault/api"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/pkg/vault"
+)
+
+// Store сохраняет запросы и ответы Review API в таблицы query_reviews и table_structures
+type Store struct {
+	client db.DB
+	closer func() error
+}
+
+// NewStore создает хранилище поверх существующего подключения.
+// Схема не применяется автоматически, см. Migrate.
+func NewStore(client db.DB) *Store {
+	return &Store{client: client}
+}
+
+// Open подключается к базе хранилища по пути Vault и применяет миграции
+func Open(ctx context.Context, vaultClient *api.Client, vaultPath string) (*Store, error) {
+	cfg, err := vault.GetConnectionConfig(ctx, vaultClient, vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage DB config from Vault: %w", err)
+	}
+
+	clientWrap, err := impl.New(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("storage db client init failed: %w", err)
+	}
+
+	if err := Migrate(ctx, clientWrap.DB()); err != nil {
+		clientWrap.Close()
+		return nil, err
+	}
+
+	return &Store{client: clientWrap.DB(), closer: clientWrap.Close}, nil
+}
+
+// Close закрывает подключение, открытое через Open
+func (s *Store) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer()
+}
+
+// Review запись о ревью одного SQL-запроса или миграции
+type Review struct {
+	SourceDatabase string
+	Environment    string
+	SQL            string
+	QueryPlan      string
+	Request        interface{} // Тело запроса к Review API, сохраняется в request_json
+	Response       interface{} // Тело ответа Review API, сохраняется в response_json
+	ThreadID       string
+	Score          *int   // nil, если ревью не удалось
+	Notes          string // Для ревью файлов — путь к SQL-файлу
+	Errors         []string
+	Tables         []models.TableInfo
+	SchedulerTask  bool // Ревью из планового запуска (--st), может прореживаться политикой хранения
+}
+
+// uuidPattern формат UUID для колонки thread_id
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// insertReviewQuery вставляет ревью и структуры таблиц одним запросом
+const insertReviewQuery = `
+	WITH review AS (
+		INSERT INTO query_reviews (
+			source_database, environment, sql_query, query_plan, request_json,
+			response_json, thread_id, overall_score, notes, errors, scheduler_task
+		)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7::uuid, $8, NULLIF($9, ''), $10, $12)
+		RETURNING id
+	), tables AS (
+		INSERT INTO table_structures (review_id, table_name, columns_info)
+		SELECT review.id,
+			COALESCE(NULLIF(t->>'schema', '') || '.', '') || (t->>'name'),
+			t
+		FROM review, jsonb_array_elements($11::jsonb) AS t
+	)
+	SELECT id FROM review`
+
+// SaveReview сохраняет ревью и связанные TableInfo, возвращает id записи query_reviews
+func (s *Store) SaveReview(ctx context.Context, r Review) (int64, error) {
+	request, err := marshalJSON(r.Request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal review request: %w", err)
+	}
+	response, err := marshalJSON(r.Response)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal review response: %w", err)
+	}
+	tables, err := json.Marshal(r.Tables)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal table structures: %w", err)
+	}
+	if r.Tables == nil {
+		tables = []byte("[]")
+	}
+
+	// Review API принимает произвольный thread_id, а колонка хранит только UUID
+	var threadID *string
+	if uuidPattern.MatchString(r.ThreadID) {
+		threadID = &r.ThreadID
+	}
+
+	environment := r.Environment
+	if environment == "" {
+		environment = "production"
+	}
+
+	var id int64
+	err = s.client.QueryRowContext(ctx, db.Query{Name: "insert_query_review", Raw: insertReviewQuery},
+		r.SourceDatabase, environment, r.SQL, r.QueryPlan, string(request), string(response),
+		threadID, r.Score, r.Notes, r.Errors, string(tables), r.SchedulerTask,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert query review: %w", err)
+	}
+
+	return id, nil
+}
+
+// marshalJSON сериализует значение для JSONB-колонки с ограничением NOT NULL
+func marshalJSON(v interface{}) ([]byte, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,234 @@
+package tuning
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
+)
+
+// Profile профиль нагрузки, под который подбирается конфигурация
+type Profile string
+
+const (
+	ProfileWeb   Profile = "web"   // Веб-приложение: много коротких запросов
+	ProfileOLTP  Profile = "oltp"  // Транзакционная нагрузка с большим числом соединений
+	ProfileDW    Profile = "dw"    // Хранилище данных: тяжёлые аналитические запросы
+	ProfileMixed Profile = "mixed" // Смешанная нагрузка
+)
+
+// Storage тип хранилища данных
+type Storage string
+
+const (
+	StorageSSD Storage = "ssd"
+	StorageHDD Storage = "hdd"
+	StorageSAN Storage = "san"
+)
+
+// ParseProfile разбирает профиль нагрузки из значения флага
+func ParseProfile(s string) (Profile, error) {
+	switch p := Profile(strings.ToLower(strings.TrimSpace(s))); p {
+	case ProfileWeb, ProfileOLTP, ProfileDW, ProfileMixed:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown workload profile %q (expected web, oltp, dw or mixed)", s)
+	}
+}
+
+// ParseStorage разбирает тип хранилища из значения флага
+func ParseStorage(s string) (Storage, error) {
+	switch st := Storage(strings.ToLower(strings.TrimSpace(s))); st {
+	case StorageSSD, StorageHDD, StorageSAN:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown storage type %q (expected ssd, hdd or san)", s)
+	}
+}
+
+// Options входные параметры рекомендателя
+type Options struct {
+	Profile        Profile
+	Storage        Storage
+	MaxConnections int // 0 — значение по умолчанию для профиля
+}
+
+// Change рекомендация по одному параметру: текущее и целевое значение в одной единице
+type Change struct {
+	Parameter   string `json:"parameter"`
+	Current     string `json:"current"`
+	Recommended string `json:"recommended"`
+	Unit        string `json:"unit,omitempty"`
+	Rationale   string `json:"rationale"`
+	Changed     bool   `json:"changed"`
+}
+
+// defaultConnections число соединений, на которое рассчитывается work_mem, если оно не задано явно
+var defaultConnections = map[Profile]int{
+	ProfileWeb:   200,
+	ProfileOLTP:  300,
+	ProfileDW:    40,
+	ProfileMixed: 100,
+}
+
+// Recommend рассчитывает целевые значения параметров по формулам pgtune
+// и сравнивает их с текущей конфигурацией. Если в current есть Settings с единицами
+// из pg_settings, текущие значения памяти берутся из них.
+func Recommend(current models.ServerData, metrics collectors.SystemMetrics, opts Options) ([]Change, error) {
+	if metrics.RAMTotal == 0 {
+		return nil, fmt.Errorf("total RAM is unknown")
+	}
+	cores := metrics.CPUCores
+	if cores < 1 {
+		cores = 1
+	}
+	connections := opts.MaxConnections
+	if connections <= 0 {
+		connections = defaultConnections[opts.Profile]
+	}
+
+	ram := int64(metrics.RAMTotal)
+	ramText := units.FormatBytes(roundDown(ram, units.MB))
+
+	sharedBuffers := ram / 4
+	effectiveCacheSize := ram * 3 / 4
+
+	maintenanceDivisor := int64(16)
+	if opts.Profile == ProfileDW {
+		maintenanceDivisor = 8
+	}
+	maintenanceWorkMem := ram / maintenanceDivisor
+	if maintenanceWorkMem > 2*units.GB {
+		maintenanceWorkMem = 2 * units.GB
+	}
+
+	walBuffers := sharedBuffers * 3 / 100
+	if walBuffers > 14*units.MB {
+		walBuffers = 16 * units.MB
+	}
+	if walBuffers < 32*units.KB {
+		walBuffers = 32 * units.KB
+	}
+
+	workMem := (ram - sharedBuffers) / (int64(connections+cores) * 3)
+	if opts.Profile == ProfileDW || opts.Profile == ProfileMixed {
+		workMem /= 2
+	}
+	if workMem < 64*units.KB {
+		workMem = 64 * units.KB
+	}
+
+	minWal, maxWal := walSizes(opts.Profile)
+
+	statisticsTarget := "100"
+	if opts.Profile == ProfileDW {
+		statisticsTarget = "500"
+	}
+
+	randomPageCost, ioConcurrency := storageCosts(opts.Storage)
+
+	changes := []Change{
+		memoryChange(current, "shared_buffers", current.Config.SharedBuffers, units.Page, sharedBuffers, units.MB,
+			fmt.Sprintf("25%% of %s RAM", ramText)),
+		memoryChange(current, "effective_cache_size", current.Config.EffectiveCacheSize, units.Page, effectiveCacheSize, units.MB,
+			fmt.Sprintf("75%% of %s RAM available for shared buffers and OS cache", ramText)),
+		memoryChange(current, "maintenance_work_mem", current.Config.MaintenanceWorkMem, units.KB, maintenanceWorkMem, units.MB,
+			fmt.Sprintf("RAM/%d for %s workload, capped at 2GB", maintenanceDivisor, opts.Profile)),
+		numericChange("checkpoint_completion_target", current.Config.CheckpointCompletionTarget, "0.9",
+			"spread checkpoint writes over 90% of the checkpoint interval"),
+		memoryChange(current, "wal_buffers", current.Config.WalBuffers, units.Page, walBuffers, units.KB,
+			"3% of shared_buffers, at most 16MB"),
+		numericChange("default_statistics_target", current.Config.DefaultStatisticsTarget, statisticsTarget,
+			fmt.Sprintf("statistics detail for %s workload", opts.Profile)),
+		numericChange("random_page_cost", current.Config.RandomPageCost, randomPageCost,
+			fmt.Sprintf("random read cost on %s storage", strings.ToUpper(string(opts.Storage)))),
+		numericChange("effective_io_concurrency", current.Config.EffectiveIOConcurrency, ioConcurrency,
+			fmt.Sprintf("concurrent I/O requests supported by %s storage", strings.ToUpper(string(opts.Storage)))),
+		memoryChange(current, "work_mem", current.Config.WorkMem, units.KB, workMem, units.KB,
+			fmt.Sprintf("(RAM - shared_buffers) / ((%d connections + %d cores) * 3) for %s workload", connections, cores, opts.Profile)),
+		memoryChange(current, "min_wal_size", current.Config.MinWalSize, units.MB, minWal, units.MB,
+			fmt.Sprintf("WAL retained between checkpoints for %s workload", opts.Profile)),
+		memoryChange(current, "max_wal_size", current.Config.MaxWalSize, units.MB, maxWal, units.MB,
+			fmt.Sprintf("checkpoint distance for %s workload", opts.Profile)),
+	}
+
+	return changes, nil
+}
+
+// unitNames имена единиц, в которых выводятся параметры памяти
+var unitNames = map[int64]string{
+	units.KB: "kB",
+	units.MB: "MB",
+}
+
+// walSizes возвращает min_wal_size и max_wal_size для профиля
+func walSizes(profile Profile) (int64, int64) {
+	switch profile {
+	case ProfileOLTP:
+		return 2 * units.GB, 8 * units.GB
+	case ProfileDW:
+		return 4 * units.GB, 16 * units.GB
+	default:
+		return 1 * units.GB, 4 * units.GB
+	}
+}
+
+// storageCosts возвращает random_page_cost и effective_io_concurrency для типа хранилища
+func storageCosts(storage Storage) (string, string) {
+	switch storage {
+	case StorageHDD:
+		return "4", "2"
+	case StorageSAN:
+		return "1.1", "300"
+	default:
+		return "1.1", "200"
+	}
+}
+
+// memoryChange сравнивает параметр памяти; оба значения выводятся в единице displayUnit
+func memoryChange(data models.ServerData, name, current string, nativeUnit, recommended, displayUnit int64, rationale string) Change {
+	recommended = roundDown(recommended, displayUnit)
+	change := Change{
+		Parameter:   name,
+		Current:     "-",
+		Recommended: strconv.FormatInt(recommended/displayUnit, 10),
+		Unit:        unitNames[displayUnit],
+		Rationale:   rationale,
+		Changed:     true,
+	}
+
+	size, ok := data.SettingBytes(name)
+	if !ok {
+		size, ok = units.ParseBytes(current, nativeUnit)
+	}
+	if ok {
+		change.Current = strconv.FormatInt(size/displayUnit, 10)
+		change.Changed = size != recommended
+	}
+	return change
+}
+
+// numericChange сравнивает безразмерный параметр
+func numericChange(name, current, recommended, rationale string) Change {
+	change := Change{
+		Parameter:   name,
+		Current:     "-",
+		Recommended: recommended,
+		Rationale:   rationale,
+		Changed:     true,
+	}
+
+	if value, ok := units.ParseFloat(current); ok {
+		change.Current = strings.TrimSpace(current)
+		target, _ := units.ParseFloat(recommended)
+		change.Changed = value != target
+	}
+	return change
+}
+
+func roundDown(value, unit int64) int64 {
+	return value / unit * unit
+}
@@ -0,0 +1,169 @@
+package tuning
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ratmirtech/postgresql-query-monitor/internal/collectors"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/models"
+	"github.com/ratmirtech/postgresql-query-monitor/internal/units"
+)
+
+// tunedSmall конфигурация, уже совпадающая с рекомендацией для 1GB RAM, 1 ядра, web и SSD.
+// Значения заданы в нативных единицах параметров и с суффиксами, чтобы проверить перевод единиц.
+var tunedSmall = models.Config{
+	SharedBuffers:              "32768", // 8kB-страницы: 256MB
+	EffectiveCacheSize:         "768MB",
+	MaintenanceWorkMem:         "65536", // kB: 64MB
+	CheckpointCompletionTarget: "0.9",
+	WalBuffers:                 "983", // 8kB-страницы: 7864kB
+	DefaultStatisticsTarget:    "100",
+	RandomPageCost:             "1.1",
+	EffectiveIOConcurrency:     "200",
+	WorkMem:                    "1304kB",
+	MinWalSize:                 "1GB",
+	MaxWalSize:                 "4096", // MB
+}
+
+func TestRecommend(t *testing.T) {
+	small := map[string]string{
+		"shared_buffers":               "256",
+		"effective_cache_size":         "768",
+		"maintenance_work_mem":         "64",
+		"checkpoint_completion_target": "0.9",
+		"wal_buffers":                  "7864",
+		"default_statistics_target":    "100",
+		"random_page_cost":             "1.1",
+		"effective_io_concurrency":     "200",
+		"work_mem":                     "1304",
+		"min_wal_size":                 "1024",
+		"max_wal_size":                 "4096",
+	}
+
+	tests := []struct {
+		name          string
+		current       models.ServerData
+		metrics       collectors.SystemMetrics
+		opts          Options
+		recommended   map[string]string
+		currentValues map[string]string // Ожидаемые текущие значения; nil — все "-"
+		changed       map[string]bool   // Параметры, которые должны измениться; nil — все
+	}{
+		{
+			name:        "small RAM without current config",
+			metrics:     collectors.SystemMetrics{RAMTotal: uint64(units.GB), CPUCores: 1},
+			opts:        Options{Profile: ProfileWeb, Storage: StorageSSD},
+			recommended: small,
+		},
+		{
+			name:    "large RAM hits maintenance_work_mem and wal_buffers caps",
+			metrics: collectors.SystemMetrics{RAMTotal: uint64(256 * units.GB), CPUCores: 32},
+			opts:    Options{Profile: ProfileDW, Storage: StorageHDD},
+			recommended: map[string]string{
+				"shared_buffers":               "65536",
+				"effective_cache_size":         "196608",
+				"maintenance_work_mem":         "2048",
+				"checkpoint_completion_target": "0.9",
+				"wal_buffers":                  "16384",
+				"default_statistics_target":    "500",
+				"random_page_cost":             "4",
+				"effective_io_concurrency":     "2",
+				"work_mem":                     "466033",
+				"min_wal_size":                 "4096",
+				"max_wal_size":                 "16384",
+			},
+		},
+		{
+			name:        "explicit max connections",
+			metrics:     collectors.SystemMetrics{RAMTotal: uint64(units.GB), CPUCores: 0},
+			opts:        Options{Profile: ProfileOLTP, Storage: StorageSAN, MaxConnections: 20},
+			recommended: map[string]string{"work_mem": "12483", "random_page_cost": "1.1", "effective_io_concurrency": "300"},
+		},
+		{
+			name:          "already tuned",
+			current:       models.ServerData{Config: tunedSmall},
+			metrics:       collectors.SystemMetrics{RAMTotal: uint64(units.GB), CPUCores: 1},
+			opts:          Options{Profile: ProfileWeb, Storage: StorageSSD},
+			recommended:   small,
+			currentValues: small,
+			changed:       map[string]bool{},
+		},
+		{
+			name: "pg_settings bytes take precedence over config",
+			current: models.ServerData{
+				Config:   tunedSmall,
+				Settings: map[string]models.Setting{"shared_buffers": {Name: "shared_buffers", Setting: "16384", Unit: "8kB", Bytes: bytesPtr(128 * units.MB)}},
+			},
+			metrics:       collectors.SystemMetrics{RAMTotal: uint64(units.GB), CPUCores: 1},
+			opts:          Options{Profile: ProfileWeb, Storage: StorageSSD},
+			recommended:   small,
+			currentValues: withValue(small, "shared_buffers", "128"),
+			changed:       map[string]bool{"shared_buffers": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Recommend(tt.current, tt.metrics, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != len(tt.current.Config.Params()) {
+				t.Errorf("got %d changes, want one per config parameter", len(changes))
+			}
+
+			for _, c := range changes {
+				if want, ok := tt.recommended[c.Parameter]; ok && c.Recommended != want {
+					t.Errorf("%s recommended = %s %s, want %s", c.Parameter, c.Recommended, c.Unit, want)
+				}
+
+				wantCurrent := "-"
+				if tt.currentValues != nil {
+					wantCurrent = tt.currentValues[c.Parameter]
+				}
+				if c.Current != wantCurrent {
+					t.Errorf("%s current = %s, want %s", c.Parameter, c.Current, wantCurrent)
+				}
+
+				wantChanged := true
+				if tt.changed != nil {
+					wantChanged = tt.changed[c.Parameter]
+				}
+				if c.Changed != wantChanged {
+					t.Errorf("%s changed = %v, want %v", c.Parameter, c.Changed, wantChanged)
+				}
+
+				// Ни одна рекомендация по памяти не превышает объём RAM
+				if size, ok := unitSizes[c.Unit]; ok && c.Parameter != "min_wal_size" && c.Parameter != "max_wal_size" {
+					n, _ := strconv.ParseInt(c.Recommended, 10, 64)
+					if n*size > int64(tt.metrics.RAMTotal) {
+						t.Errorf("%s = %s %s exceeds total RAM", c.Parameter, c.Recommended, c.Unit)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendUnknownRAM(t *testing.T) {
+	if _, err := Recommend(models.ServerData{}, collectors.SystemMetrics{CPUCores: 4}, Options{Profile: ProfileWeb}); err == nil {
+		t.Error("expected an error when total RAM is unknown")
+	}
+}
+
+// unitSizes размеры единиц, в которых выводятся параметры памяти
+var unitSizes = map[string]int64{"kB": units.KB, "MB": units.MB}
+
+func bytesPtr(n int64) *int64 {
+	return &n
+}
+
+// withValue возвращает копию values с изменённым значением key
+func withValue(values map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
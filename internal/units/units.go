@@ -0,0 +1,163 @@
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Размеры единиц памяти, используемых в pg_settings
+const (
+	Byte int64 = 1
+	KB   int64 = 1024
+	Page int64 = 8 * KB // Блок PostgreSQL (BLCKSZ)
+	MB   int64 = 1024 * KB
+	GB   int64 = 1024 * MB
+	TB   int64 = 1024 * GB
+)
+
+var byteSuffixes = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", TB},
+	{"GB", GB},
+	{"MB", MB},
+	{"kB", KB},
+	{"B", Byte},
+}
+
+// ParseBytes переводит значение параметра в байты. Значение без суффикса
+// умножается на нативную единицу параметра (например, 8kB для shared_buffers).
+func ParseBytes(value string, nativeUnit int64) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	multiplier := nativeUnit
+	for _, s := range byteSuffixes {
+		if strings.HasSuffix(value, s.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, s.suffix))
+			multiplier = s.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// FormatBytes форматирует размер в крупнейшей единице PostgreSQL, которой он кратен (например, 128MB)
+func FormatBytes(bytes int64) string {
+	if bytes == 0 {
+		return "0"
+	}
+	for _, s := range byteSuffixes {
+		if s.size > Byte && bytes%s.size == 0 {
+			return strconv.FormatInt(bytes/s.size, 10) + s.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10) + "B"
+}
+
+// ParseFloat разбирает числовое значение параметра
+func ParseFloat(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// Единицы времени в миллисекундах
+const (
+	Microsecond float64 = 0.001
+	Millisecond float64 = 1
+	Second      float64 = 1000
+	Minute      float64 = 60 * Second
+	Hour        float64 = 60 * Minute
+	Day         float64 = 24 * Hour
+)
+
+// Kind тип единицы измерения параметра
+type Kind int
+
+const (
+	KindNone Kind = iota
+	KindBytes
+	KindTime
+)
+
+var timeUnits = map[string]float64{
+	"us":  Microsecond,
+	"ms":  Millisecond,
+	"s":   Second,
+	"min": Minute,
+	"h":   Hour,
+	"d":   Day,
+}
+
+// ParseUnit разбирает колонку unit из pg_settings (например, "8kB", "MB", "ms", "min").
+// Для памяти множитель задаётся в байтах, для времени — в миллисекундах.
+func ParseUnit(unit string) (Kind, float64) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		return KindNone, 1
+	}
+
+	if ms, ok := timeUnits[unit]; ok {
+		return KindTime, ms
+	}
+
+	// Числовой префикс встречается у блочных единиц: "8kB", "16MB"
+	i := 0
+	for i < len(unit) && unit[i] >= '0' && unit[i] <= '9' {
+		i++
+	}
+	factor := int64(1)
+	if i > 0 {
+		n, err := strconv.ParseInt(unit[:i], 10, 64)
+		if err != nil {
+			return KindNone, 1
+		}
+		factor = n
+	}
+
+	for _, s := range byteSuffixes {
+		if unit[i:] == s.suffix {
+			return KindBytes, float64(factor * s.size)
+		}
+	}
+	return KindNone, 1
+}
+
+// durationUnits единицы времени PostgreSQL от крупной к мелкой
+var durationUnits = []struct {
+	name string
+	size float64
+}{
+	{"d", Day},
+	{"h", Hour},
+	{"min", Minute},
+	{"s", Second},
+	{"ms", Millisecond},
+}
+
+// FormatDuration форматирует длительность в миллисекундах в крупнейшей кратной единице PostgreSQL
+func FormatDuration(ms float64) string {
+	if ms == 0 || ms != math.Trunc(ms) {
+		return strconv.FormatFloat(ms, 'f', -1, 64) + "ms"
+	}
+
+	whole := int64(ms)
+	for _, u := range durationUnits {
+		if whole%int64(u.size) == 0 {
+			return strconv.FormatInt(whole/int64(u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(whole, 10) + "ms"
+}
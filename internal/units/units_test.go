@@ -0,0 +1,106 @@
+package units
+
+import "testing"
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		unit       string
+		kind       Kind
+		multiplier float64
+	}{
+		{"", KindNone, 1},
+		{"8kB", KindBytes, 8192},
+		{"16MB", KindBytes, 16 * 1024 * 1024},
+		{"kB", KindBytes, 1024},
+		{"MB", KindBytes, 1024 * 1024},
+		{"B", KindBytes, 1},
+		{"us", KindTime, 0.001},
+		{"ms", KindTime, 1},
+		{"s", KindTime, 1000},
+		{"min", KindTime, 60000},
+		{"h", KindTime, 3600000},
+		{"d", KindTime, 86400000},
+		{" ms ", KindTime, 1},
+		{"8", KindNone, 1},
+		{"8XB", KindNone, 1},
+		{"pages", KindNone, 1},
+	}
+	for _, tt := range tests {
+		kind, multiplier := ParseUnit(tt.unit)
+		if kind != tt.kind || multiplier != tt.multiplier {
+			t.Errorf("ParseUnit(%q) = %d, %v, want %d, %v", tt.unit, kind, multiplier, tt.kind, tt.multiplier)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		value  string
+		native int64
+		want   int64
+		ok     bool
+	}{
+		{"16384", Page, 128 * MB, true},
+		{"65536", KB, 64 * MB, true},
+		{"128MB", Page, 128 * MB, true},
+		{"8kB", MB, 8 * KB, true},
+		{" 1 GB ", MB, GB, true},
+		{"2TB", MB, 2 * TB, true},
+		{"100B", KB, 100, true},
+		{"-1", Page, -Page, true},
+		{"", KB, 0, false},
+		{"1.5GB", MB, 0, false},
+		{"lots", KB, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseBytes(tt.value, tt.native)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseBytes(%q, %d) = %d, %v, want %d, %v", tt.value, tt.native, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0"},
+		{100, "100B"},
+		{KB, "1kB"},
+		{8 * KB, "8kB"},
+		{1536 * KB, "1536kB"},
+		{128 * MB, "128MB"},
+		{4 * GB, "4GB"},
+		{TB, "1TB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		ms   float64
+		want string
+	}{
+		{0, "0ms"},
+		{0.5, "0.5ms"},
+		{1.25, "1.25ms"},
+		{1500.5, "1500.5ms"},
+		{200, "200ms"},
+		{1500, "1500ms"},
+		{5000, "5s"},
+		{90000, "90s"},
+		{300000, "5min"},
+		{7200000, "2h"},
+		{86400000, "1d"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.ms); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %s, want %s", tt.ms, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+-- Mark reviews produced by scheduled (--st) runs so retention can downsample them
+
+ALTER TABLE query_reviews ADD COLUMN IF NOT EXISTS scheduler_task BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_query_reviews_scheduler_task ON query_reviews(scheduler_task) WHERE scheduler_task;
+
+COMMENT ON COLUMN query_reviews.scheduler_task IS 'Review was produced by a scheduled run and may be downsampled by retention';
@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-схему хранилища результатов ревью.
+// Файлы применяются по порядку имён пакетом internal/storage.
+package migrations
+
+import "embed"
+
+// FS встроенные файлы миграций вида NNN_description.sql
+//
+//go:embed *.sql
+var FS embed.FS